@@ -1,16 +1,22 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"aicodeprep-go/internal/clipboard"
 	"aicodeprep-go/internal/config"
 	"aicodeprep-go/internal/formatter"
+	"aicodeprep-go/internal/git"
+	"aicodeprep-go/internal/i18n"
 	"aicodeprep-go/internal/interactive"
 	"aicodeprep-go/internal/selector"
+	"aicodeprep-go/internal/tokenizer"
 )
 
 var (
@@ -23,8 +29,41 @@ var (
 	dryRun           bool
 	maxSize          int64
 	verbose          bool
+	noGitignore      bool
+	root             string
+	explain          bool
+	gitTracked       bool
+	gitChanged       bool
+	since            string
+	diff             bool
+	diffContext      int
+	diffOnly         bool
+	rev              string
+	format           string
+	promptPosition   string
+	templatePath     string
+	lang             string
+	encoding         string
+	encodingFile     string
+	sortBy           string
+	maxTokens        int
+	pins             []string
+	partBytes        int
+	partTokens       int
+	tree             string
+	lineNumbers      bool
+	maxLineLength    int
+	binPlaceholders  bool
+	skeleton         []string
+	symbols          []string
+	symbolDepth      int
+	importDepth      int
+	tests            string
 )
 
+// messages is the language of the generated prompt and the UI
+var messages = i18n.English
+
 var rootCmd = &cobra.Command{
 	Use:   "aicodeprep-go",
 	Short: "Generate LLM prompts with multiple code files",
@@ -44,6 +83,38 @@ func init() {
 	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show files that would be processed")
 	rootCmd.Flags().Int64Var(&maxSize, "max-size", 0, "Maximum file size in bytes (default: 1MB)")
 	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	rootCmd.Flags().BoolVar(&noGitignore, "no-gitignore", false, "Don't apply .gitignore rules")
+	rootCmd.Flags().StringVar(&root, "root", "", "Project root for exclude rules (default: git repository root)")
+	rootCmd.Flags().BoolVar(&explain, "explain", false, "Report which rule excluded each file")
+	rootCmd.Flags().BoolVar(&gitTracked, "git-tracked", false, "Select files tracked by git")
+	rootCmd.Flags().BoolVar(&gitChanged, "git-changed", false, "Select staged, unstaged and untracked changes")
+	rootCmd.Flags().StringVar(&since, "since", "", "Select files changed since a git ref")
+	rootCmd.Flags().StringVar(&format, "format", "", "Output format: text, markdown, xml, json or jsonl (default: text)")
+	rootCmd.Flags().StringVar(&templatePath, "template", "", "Render output through a Go text/template file")
+	rootCmd.Flags().StringVar(&promptPosition, "prompt-position", "", "Where to put the prompt: top, bottom or both (default: both)")
+	rootCmd.Flags().StringVar(&rev, "rev", "", "Read files from a git revision instead of the working tree")
+	rootCmd.Flags().BoolVar(&diff, "diff", false, "Include the git diff against --since (default: HEAD)")
+	rootCmd.Flags().IntVar(&diffContext, "diff-context", 3, "Number of context lines in the diff")
+	rootCmd.Flags().BoolVar(&diffOnly, "diff-only", false, "Emit only the diff hunks instead of full files")
+	rootCmd.Flags().StringVar(&encoding, "encoding", "", "Token encoding: heuristic, or cl100k_base or o200k_base with --encoding-file (default: heuristic)")
+	rootCmd.Flags().StringVar(&encodingFile, "encoding-file", "", "tiktoken vocabulary file for the encoding")
+	rootCmd.Flags().StringVar(&sortBy, "sort", "", "Order of the file report: path, size or tokens (default: selection order)")
+	rootCmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Token budget; drop and truncate low-priority files to fit")
+	rootCmd.Flags().StringArrayVar(&pins, "pin", []string{}, "Files kept first when cutting to --max-tokens (can be used multiple times)")
+	rootCmd.Flags().IntVar(&partBytes, "part-bytes", 0, "Split the output into numbered parts of at most this many bytes")
+	rootCmd.Flags().IntVar(&partTokens, "part-tokens", 0, "Split the output into numbered parts of at most this many tokens")
+	rootCmd.Flags().StringVar(&tree, "tree", "", "Show a directory tree before the files: selected or full (default: selected)")
+	rootCmd.Flags().Lookup("tree").NoOptDefVal = "selected"
+	rootCmd.Flags().BoolVar(&lineNumbers, "line-numbers", false, "Prefix each line of the file contents with its number")
+	rootCmd.Flags().IntVar(&maxLineLength, "max-line-length", 0, "Truncate lines longer than this many bytes (default: no limit)")
+	rootCmd.Flags().BoolVar(&binPlaceholders, "binary-placeholders", false, "List binary files as placeholders instead of excluding them")
+	rootCmd.Flags().StringArrayVar(&skeleton, "skeleton", []string{}, "Render matching Go files as declarations only, \"!\" to keep files in full (can be used multiple times)")
+	rootCmd.Flags().StringArrayVar(&symbols, "symbol", []string{}, "Emit only this Go declaration, as pkg.Name or pkg.Type.Method, and the declarations it references (can be used multiple times)")
+	rootCmd.Flags().IntVar(&symbolDepth, "symbol-depth", 1, "Levels of referenced declarations added to --symbol")
+	rootCmd.Flags().IntVar(&importDepth, "import-depth", 0, "Add the local files imported by the selected Go, JavaScript, TypeScript and Python files, up to this many levels")
+	rootCmd.Flags().StringVar(&tests, "tests", "", "Add the test files of selected sources, the sources of selected tests, or both (default: both)")
+	rootCmd.Flags().Lookup("tests").NoOptDefVal = "both"
+	rootCmd.Flags().StringVar(&lang, "lang", "", "Language of the prompt and messages: en, zh or ja (default: from LANG)")
 }
 
 func main() {
@@ -76,6 +147,103 @@ func runCommand(cmd *cobra.Command, args []string) error {
 
 	// Merge command line options with config
 	cfg.Merge(files, excludes, prompt, output, maxSize)
+	if noGitignore {
+		cfg.Gitignore = false
+	}
+	if root != "" {
+		cfg.Root = root
+	}
+	if gitTracked && gitChanged {
+		return fmt.Errorf("--git-tracked and --git-changed can't be used together")
+	}
+	if gitTracked {
+		cfg.Git = "tracked"
+	}
+	if gitChanged {
+		cfg.Git = "changed"
+	}
+	if since != "" {
+		cfg.Since = since
+	}
+	if rev != "" {
+		cfg.Rev = rev
+	}
+	if format != "" {
+		cfg.Format = format
+	}
+	if promptPosition != "" {
+		cfg.PromptPosition = promptPosition
+	}
+	if templatePath != "" {
+		cfg.Template = templatePath
+	}
+	if diff {
+		cfg.Diff = true
+	}
+	if cmd.Flags().Changed("diff-context") {
+		cfg.DiffContext = diffContext
+	}
+	if diffOnly {
+		cfg.DiffOnly = true
+	}
+	if lang != "" {
+		cfg.Lang = lang
+	}
+	if encoding != "" {
+		cfg.Encoding = encoding
+	}
+	if encodingFile != "" {
+		cfg.EncodingFile = encodingFile
+	}
+	if sortBy != "" {
+		cfg.Sort = sortBy
+	}
+	if maxTokens > 0 {
+		cfg.MaxTokens = maxTokens
+	}
+	if len(pins) > 0 {
+		cfg.Pin = append(cfg.Pin, pins...)
+	}
+	if partBytes > 0 {
+		cfg.PartBytes = partBytes
+	}
+	if partTokens > 0 {
+		cfg.PartTokens = partTokens
+	}
+	if tree != "" {
+		cfg.Tree = tree
+	}
+	if lineNumbers {
+		cfg.LineNumbers = true
+	}
+	if maxLineLength > 0 {
+		cfg.MaxLineLength = maxLineLength
+	}
+	if binPlaceholders {
+		cfg.BinaryPlaceholders = true
+	}
+	if len(skeleton) > 0 {
+		cfg.Skeleton = append(cfg.Skeleton, skeleton...)
+	}
+	if len(symbols) > 0 {
+		cfg.Symbols = append(cfg.Symbols, symbols...)
+	}
+	if cmd.Flags().Changed("symbol-depth") {
+		cfg.SymbolDepth = symbolDepth
+	}
+	if importDepth > 0 {
+		cfg.ImportDepth = importDepth
+	}
+	if tests != "" {
+		cfg.Tests = tests
+	}
+
+	var err error
+	messages, err = i18n.Lookup(cfg.Lang)
+	if err != nil {
+		return err
+	}
+	clipboard.SetMessages(messages)
 
 	// Handle interactive mode
 	if interactive_mode {
@@ -83,9 +251,9 @@ func runCommand(cmd *cobra.Command, args []string) error {
 	}
 
 	// If no files specified and no config, ask for help
-	if len(cfg.Files) == 0 {
+	if len(cfg.Files) == 0 && cfg.Git == "" && cfg.Since == "" && cfg.Rev == "" {
 		if verbose {
-			fmt.Fprintf(os.Stderr, "No files specified, using current directory pattern\n")
+			fmt.Fprintln(os.Stderr, messages.NoFilesSpecified)
 		}
 		cfg.Files = []string{"*"}
 	}
@@ -95,6 +263,8 @@ func runCommand(cmd *cobra.Command, args []string) error {
 
 func runInteractiveMode(cfg *config.Config) error {
 	ih := interactive.New()
+	ih.SetMessages(messages)
+	clipboard.SetInput(ih.Scanner())
 
 	// Get prompt if not provided
 	if cfg.Prompt == "" {
@@ -106,7 +276,7 @@ func runInteractiveMode(cfg *config.Config) error {
 	}
 
 	// Get file patterns if not provided
-	if len(cfg.Files) == 0 {
+	if len(cfg.Files) == 0 && cfg.Git == "" && cfg.Since == "" && cfg.Rev == "" {
 		patterns, err := ih.GetFilePatterns()
 		if err != nil {
 			return fmt.Errorf("failed to get file patterns: %w", err)
@@ -133,14 +303,13 @@ func runInteractiveMode(cfg *config.Config) error {
 	}
 
 	// Select files
-	fs := selector.New(cfg.Files, cfg.Exclude, cfg.MaxFileSize)
-	selectedFiles, err := fs.SelectFiles()
+	fs, selectedFiles, err := selectFiles(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to select files: %w", err)
+		return err
 	}
 
 	if len(selectedFiles) == 0 {
-		fmt.Fprintf(os.Stderr, "No files found matching the patterns\n")
+		fmt.Fprintln(os.Stderr, messages.NoFilesFound)
 		return nil
 	}
 
@@ -150,20 +319,137 @@ func runInteractiveMode(cfg *config.Config) error {
 		return fmt.Errorf("failed to select from list: %w", err)
 	}
 
-	return generateOutput(cfg, finalFiles)
+	return generateOutput(cfg, fs, finalFiles)
+}
+
+// selectFiles runs a FileSelector configured from cfg and returns it along
+// with the selected files
+func selectFiles(cfg *config.Config) (*selector.FileSelector, []selector.FileInfo, error) {
+	fs, err := newSelector(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selectedFiles, err := fs.SelectFiles()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to select files: %w", err)
+	}
+
+	if explain {
+		printExclusions(fs)
+	}
+
+	return fs, selectedFiles, nil
+}
+
+// newSelector creates a FileSelector configured from cfg
+func newSelector(cfg *config.Config) (*selector.FileSelector, error) {
+	fs := selector.New(cfg.Files, cfg.Exclude, cfg.MaxFileSize)
+	if cfg.Root != "" {
+		if err := fs.SetRoot(cfg.Root); err != nil {
+			return nil, err
+		}
+	}
+	fs.SetRespectGitignore(cfg.Gitignore)
+	fs.SetVerbose(verbose)
+	fs.SetMessages(messages)
+	fs.SetPins(cfg.Pin)
+	fs.SetBinaryPlaceholders(cfg.BinaryPlaceholders)
+	for pattern, name := range cfg.FileEncodings {
+		if err := formatter.LookupEncoding(name); err != nil {
+			return nil, fmt.Errorf("invalid encoding for '%s': %w", pattern, err)
+		}
+	}
+	fs.SetEncodings(cfg.FileEncodings)
+	fs.SetSkeleton(cfg.Skeleton)
+	fs.SetImportDepth(cfg.ImportDepth)
+	pairing, err := parseTestPairing(cfg.Tests)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.SetTestPairing(pairing, cfg.TestConventions); err != nil {
+		return nil, err
+	}
+
+	if cfg.Rev != "" {
+		// With --rev, --since only sets the base of the diff
+		if cfg.Git != "" {
+			return nil, fmt.Errorf("--rev can't be combined with --git-tracked or --git-changed")
+		}
+		fs.SetRevision(cfg.Rev)
+	} else {
+		gitMode, err := parseGitMode(cfg)
+		if err != nil {
+			return nil, err
+		}
+		fs.SetGitMode(gitMode, cfg.Since)
+	}
+
+	return fs, nil
+}
+
+// parseGitMode determines how candidates are taken from git
+func parseGitMode(cfg *config.Config) (selector.GitMode, error) {
+	if cfg.Since != "" {
+		if cfg.Git != "" {
+			return selector.GitNone, fmt.Errorf("--since can't be combined with --git-tracked or --git-changed")
+		}
+		return selector.GitSince, nil
+	}
+
+	switch cfg.Git {
+	case "":
+		return selector.GitNone, nil
+	case "tracked":
+		return selector.GitTracked, nil
+	case "changed":
+		return selector.GitChanged, nil
+	default:
+		return selector.GitNone, fmt.Errorf("invalid git mode '%s' (expected tracked or changed)", cfg.Git)
+	}
+}
+
+// parseTestPairing determines which counterparts of the selected files are added
+func parseTestPairing(mode string) (selector.TestPairing, error) {
+	switch mode {
+	case "":
+		return selector.TestsNone, nil
+	case "tests":
+		return selector.TestsOnly, nil
+	case "sources":
+		return selector.TestsSources, nil
+	case "both":
+		return selector.TestsBoth, nil
+	default:
+		return selector.TestsNone, fmt.Errorf("invalid tests mode '%s' (expected tests, sources or both)", mode)
+	}
+}
+
+// printExclusions reports which rule removed each path
+func printExclusions(fs *selector.FileSelector) {
+	exclusions := fs.Exclusions()
+	fmt.Fprintf(os.Stderr, messages.ProjectRoot+"\n", fs.Root())
+	if len(exclusions) == 0 {
+		fmt.Fprintln(os.Stderr, messages.NoExclusions)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, messages.ExcludedPaths+"\n", len(exclusions))
+	for _, exclusion := range exclusions {
+		fmt.Fprintf(os.Stderr, "  %s <- %s\n", exclusion.Path, exclusion.Reason)
+	}
 }
 
 func runBatchMode(cfg *config.Config) error {
 	// Select files
-	fs := selector.New(cfg.Files, cfg.Exclude, cfg.MaxFileSize)
-	selectedFiles, err := fs.SelectFiles()
+	fs, selectedFiles, err := selectFiles(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to select files: %w", err)
+		return err
 	}
 
 	if len(selectedFiles) == 0 {
 		if verbose {
-			fmt.Fprintf(os.Stderr, "No files found matching the patterns:\n")
+			fmt.Fprintln(os.Stderr, messages.NoFilesMatching)
 			for _, pattern := range cfg.Files {
 				fmt.Fprintf(os.Stderr, "  - %s\n", pattern)
 			}
@@ -175,8 +461,7 @@ func runBatchMode(cfg *config.Config) error {
 	validFiles := formatter.ValidateFiles(selectedFiles)
 	if len(validFiles) != len(selectedFiles) {
 		if verbose {
-			fmt.Fprintf(os.Stderr, "Warning: %d files were skipped (not readable or not regular files)\n",
-				len(selectedFiles)-len(validFiles))
+			fmt.Fprintf(os.Stderr, messages.SkippedFiles+"\n", len(selectedFiles)-len(validFiles))
 		}
 	}
 
@@ -187,34 +472,169 @@ func runBatchMode(cfg *config.Config) error {
 	// Dry run mode
 	if dryRun {
 		pf := formatter.New("", validFiles, verbose)
+		pf.SetMessages(messages)
+		if err := setupTokens(pf, cfg); err != nil {
+			return err
+		}
 		fmt.Print(pf.GetSummary())
 		return nil
 	}
 
-	return generateOutput(cfg, validFiles)
+	return generateOutput(cfg, fs, validFiles)
 }
 
-func generateOutput(cfg *config.Config, files []selector.FileInfo) error {
+func generateOutput(cfg *config.Config, fs *selector.FileSelector, files []selector.FileInfo) error {
 	// Format the prompt
 	pf := formatter.New(cfg.Prompt, files, verbose)
+	pf.SetMessages(messages)
+	pf.SetFormat(cfg.Format)
+	if verbose || cfg.MaxTokens > 0 || cfg.PartTokens > 0 {
+		if err := setupTokens(pf, cfg); err != nil {
+			return err
+		}
+		pf.SetMaxTokens(cfg.MaxTokens)
+	}
+	switch {
+	case cfg.PartBytes > 0 && cfg.PartTokens > 0:
+		return fmt.Errorf("--part-bytes and --part-tokens can't be used together")
+	case cfg.PartBytes > 0:
+		pf.SetPartLimit(cfg.PartBytes, false)
+	case cfg.PartTokens > 0:
+		pf.SetPartLimit(cfg.PartTokens, true)
+	}
+	pf.SetPromptPosition(cfg.PromptPosition)
+	pf.SetLineNumbers(cfg.LineNumbers)
+	pf.SetMaxLineLength(cfg.MaxLineLength)
+	switch cfg.XMLContent {
+	case "cdata", "":
+		pf.SetXMLCDATA(true)
+	case "escape":
+		pf.SetXMLCDATA(false)
+	default:
+		return fmt.Errorf("invalid xml_content '%s' (expected cdata or escape)", cfg.XMLContent)
+	}
+	if cfg.Template != "" {
+		if err := pf.SetTemplate(cfg.Template); err != nil {
+			return err
+		}
+	}
+	if len(cfg.Symbols) > 0 {
+		if err := pf.SetSymbols(cfg.Symbols, cfg.SymbolDepth); err != nil {
+			return err
+		}
+	}
+	if err := setupTree(pf, cfg, fs); err != nil {
+		return err
+	}
+
+	if cfg.Diff || cfg.DiffOnly {
+		diffText, err := loadDiff(cfg, fs.Root(), files)
+		if err != nil {
+			return fmt.Errorf("failed to load diff: %w", err)
+		}
+		pf.SetDiff(diffText, cfg.DiffOnly)
+	}
 
 	if verbose {
-		fmt.Fprintf(os.Stderr, "Formatting %d files...\n", len(files))
+		fmt.Fprintf(os.Stderr, messages.FormattingFiles+"\n", len(files))
 	}
 
-	formattedPrompt, err := pf.Format()
+	parts, err := pf.FormatParts()
 	if err != nil {
 		return fmt.Errorf("failed to format prompt: %w", err)
 	}
+	printCuts(pf.Cuts(), cfg.MaxTokens)
 
 	// Write output
-	if err := clipboard.WriteToOutput(formattedPrompt, cfg.Output, verbose); err != nil {
+	if err := clipboard.WriteParts(parts, cfg.Output, verbose); err != nil {
 		return fmt.Errorf("failed to write output: %w", err)
 	}
 
 	if verbose && cfg.Output == "" {
-		fmt.Fprintf(os.Stderr, "Prompt generated successfully with %d files\n", len(files))
+		fmt.Fprintf(os.Stderr, messages.PromptGenerated+"\n", len(files))
+	}
+
+	return nil
+}
+
+// setupTree adds the directory tree preamble selected by cfg.Tree,
+// relative to the project root of fs, which also lists the full tree
+func setupTree(pf *formatter.PromptFormatter, cfg *config.Config, fs *selector.FileSelector) error {
+	switch cfg.Tree {
+	case "":
+		return nil
+	case "selected", "full":
+	default:
+		return fmt.Errorf("invalid tree '%s' (expected selected or full)", cfg.Tree)
 	}
 
+	var all []selector.FileInfo
+	if cfg.Tree == "full" {
+		var err error
+		all, err = fs.ListAll()
+		if err != nil {
+			return err
+		}
+	}
+	pf.SetTree(all, fs.Root())
 	return nil
 }
+
+// printCuts reports the files cut to fit the token budget
+func printCuts(cuts []formatter.Cut, budget int) {
+	if len(cuts) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, messages.BudgetCuts+"\n", len(cuts), budget)
+	for _, cut := range cuts {
+		if cut.Dropped {
+			fmt.Fprintf(os.Stderr, "  %s: %s\n", cut.Path, messages.CutDropped)
+		} else {
+			fmt.Fprintf(os.Stderr, "  %s: %s\n", cut.Path, fmt.Sprintf(messages.CutTruncated, cut.Removed, cut.Lines))
+		}
+	}
+}
+
+// setupTokens enables token counts in the report of pf, estimating them
+// with a warning when the encoding's vocabulary isn't available
+func setupTokens(pf *formatter.PromptFormatter, cfg *config.Config) error {
+	if err := pf.SetSortBy(cfg.Sort); err != nil {
+		return err
+	}
+
+	tok, err := tokenizer.New(cfg.Encoding, cfg.EncodingFile)
+	if errors.Is(err, tokenizer.ErrNoVocabulary) {
+		return fmt.Errorf("%w, set --encoding-file or encoding_file to its tiktoken rank file", err)
+	} else if err != nil {
+		return err
+	}
+
+	pf.SetTokenizer(tok)
+	return nil
+}
+
+// loadDiff returns the diff of the selected files from --since, or HEAD,
+// to --rev or the working tree, taken from the repository at the project root
+func loadDiff(cfg *config.Config, root string, files []selector.FileInfo) (string, error) {
+	repo, err := git.Open(root)
+	if err != nil {
+		return "", err
+	}
+
+	base := cfg.Since
+	if base == "" {
+		base = "HEAD"
+	}
+
+	var paths []string
+	for _, file := range files {
+		relPath, err := filepath.Rel(repo.Root, file.Path)
+		if err != nil || strings.HasPrefix(relPath, "..") {
+			continue // Not part of the repository
+		}
+		paths = append(paths, filepath.ToSlash(relPath))
+	}
+
+	return repo.Diff(base, cfg.Rev, paths, cfg.DiffContext)
+}
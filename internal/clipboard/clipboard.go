@@ -1,16 +1,38 @@
 package clipboard
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 
 	"golang.org/x/text/encoding/simplifiedchinese"
 	"golang.org/x/text/transform"
+
+	"aicodeprep-go/internal/i18n"
 )
 
+// messages is the language of the notices printed to stderr
+var messages = i18n.English
+
+// SetMessages sets the language of the notices printed to stderr
+func SetMessages(m *i18n.Messages) {
+	messages = m
+}
+
+// input reads the Enter presses between clipboard parts
+var input *bufio.Scanner
+
+// SetInput shares the scanner reading standard input with an earlier reader,
+// so lines it has already buffered aren't lost
+func SetInput(scanner *bufio.Scanner) {
+	input = scanner
+}
+
 // CopyToClipboard copies text to the system clipboard
 func CopyToClipboard(text string) error {
 	var cmd *exec.Cmd
@@ -78,18 +100,18 @@ func WriteToOutput(text, output string, verbose bool) error {
 		if IsClipboardSupported() {
 			if err := CopyToClipboard(text); err != nil {
 				if verbose {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to copy to clipboard: %v\n", err)
-					fmt.Fprintf(os.Stderr, "Writing to file 'prompt.txt' instead\n")
+					fmt.Fprintf(os.Stderr, messages.ClipboardFailed+"\n", err)
+					fmt.Fprintf(os.Stderr, messages.FallbackToFile+"\n", "prompt.txt")
 				}
 				return writeToFile(text, "prompt.txt")
 			}
 			if verbose {
-				fmt.Fprintf(os.Stderr, "Content copied to clipboard successfully\n")
+				fmt.Fprintln(os.Stderr, messages.ClipboardCopied)
 			}
 			return nil
 		} else {
 			if verbose {
-				fmt.Fprintf(os.Stderr, "Clipboard not supported, writing to file 'prompt.txt'\n")
+				fmt.Fprintf(os.Stderr, messages.ClipboardMissing+"\n", "prompt.txt")
 			}
 			return writeToFile(text, "prompt.txt")
 		}
@@ -99,6 +121,56 @@ func WriteToOutput(text, output string, verbose bool) error {
 	return writeToFile(text, output)
 }
 
+// WriteParts writes the parts of a split prompt. With a file output each part
+// goes to its own numbered file, e.g. prompt.part1.txt; otherwise the parts are
+// copied to the clipboard one at a time, waiting for Enter in between.
+func WriteParts(parts []string, output string, verbose bool) error {
+	if len(parts) == 1 {
+		return WriteToOutput(parts[0], output, verbose)
+	}
+
+	if output == "" && IsClipboardSupported() {
+		if input == nil {
+			input = bufio.NewScanner(os.Stdin)
+		}
+		for i, part := range parts {
+			if i > 0 {
+				fmt.Fprint(os.Stderr, messages.PressEnter)
+				if !input.Scan() {
+					err := input.Err()
+					if err == nil {
+						err = io.EOF
+					}
+					return fmt.Errorf("failed to read input: %w", err)
+				}
+			}
+
+			if err := CopyToClipboard(part); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, messages.PartCopied+"\n", i+1, len(parts))
+		}
+		return nil
+	}
+
+	if output == "" {
+		output = "prompt.txt"
+	}
+	for i, part := range parts {
+		if err := writeToFile(part, PartPath(output, i+1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PartPath returns the file name of part i of a split output,
+// e.g. prompt.part2.txt for prompt.txt
+func PartPath(output string, i int) string {
+	ext := filepath.Ext(output)
+	return fmt.Sprintf("%s.part%d%s", strings.TrimSuffix(output, ext), i, ext)
+}
+
 // writeToFile writes text to a specified file
 func writeToFile(text, filename string) error {
 	file, err := os.Create(filename)
@@ -111,6 +183,6 @@ func writeToFile(text, filename string) error {
 		return fmt.Errorf("failed to write to output file: %w", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "Content written to file: %s\n", filename)
+	fmt.Fprintf(os.Stderr, messages.WrittenToFile+"\n", filename)
 	return nil
 }
\ No newline at end of file
@@ -11,21 +11,67 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	Files       []string `yaml:"files"`
-	Exclude     []string `yaml:"exclude"`
-	Prompt      string   `yaml:"prompt"`
-	MaxFileSize int64    `yaml:"max_file_size"`
-	Output      string   `yaml:"output"`
+	Files              []string            `yaml:"files"`
+	Exclude            []string            `yaml:"exclude"`
+	Prompt             string              `yaml:"prompt"`
+	MaxFileSize        int64               `yaml:"max_file_size"`
+	Output             string              `yaml:"output"`
+	Gitignore          bool                `yaml:"gitignore"`
+	Root               string              `yaml:"root"`
+	Git                string              `yaml:"git"`             // "tracked" or "changed"
+	Since              string              `yaml:"since"`           // Select files changed since this ref
+	Rev                string              `yaml:"rev"`             // Read files from this revision instead of the working tree
+	Format             string              `yaml:"format"`          // text, markdown, xml, json or jsonl
+	PromptPosition     string              `yaml:"prompt_position"` // top, bottom or both
+	XMLContent         string              `yaml:"xml_content"`     // cdata or escape
+	Template           string              `yaml:"template"`        // text/template file, overrides format
+	Diff               bool                `yaml:"diff"`
+	DiffContext        int                 `yaml:"diff_context"`
+	DiffOnly           bool                `yaml:"diff_only"`
+	Lang               string              `yaml:"lang"`          // en, zh or ja, detected from the environment when empty
+	Encoding           string              `yaml:"encoding"`      // heuristic, cl100k_base or o200k_base
+	EncodingFile       string              `yaml:"encoding_file"` // tiktoken rank file of a BPE encoding
+	Sort               string              `yaml:"sort"`          // Order of the file report: path, size or tokens
+	MaxTokens          int                 `yaml:"max_tokens"`    // Token budget of the output, 0 for no limit
+	Pin                []string            `yaml:"pin"`           // Files kept first when cutting to max_tokens
+	PartBytes          int                 `yaml:"part_bytes"`    // Split the output into parts of at most this many bytes
+	PartTokens         int                 `yaml:"part_tokens"`   // Split the output into parts of at most this many tokens
+	Tree               string              `yaml:"tree"`          // Directory tree before the files: selected or full
+	LineNumbers        bool                `yaml:"line_numbers"`
+	MaxLineLength      int                 `yaml:"max_line_length"`     // Truncate longer lines, 0 for no limit
+	BinaryPlaceholders bool                `yaml:"binary_placeholders"` // List binary files as placeholders instead of excluding them
+	FileEncodings      map[string]string   `yaml:"file_encodings"`      // Forced file encodings by glob, e.g. "legacy/**": gbk
+	Skeleton           []string            `yaml:"skeleton"`            // Go files rendered as declarations only, "!" to exclude
+	Symbols            []string            `yaml:"symbols"`             // Go declarations to extract, e.g. formatter.PromptFormatter.Format
+	SymbolDepth        int                 `yaml:"symbol_depth"`        // Levels of referenced declarations added to the symbols
+	ImportDepth        int                 `yaml:"import_depth"`        // Levels of imported local files added to the selection
+	Tests              string              `yaml:"tests"`               // Add test counterparts: tests, sources or both
+	TestConventions    map[string][]string `yaml:"test_conventions"`    // Test file templates by source file template
 }
 
 // DefaultConfig returns a configuration with sensible defaults
 func DefaultConfig() *Config {
 	return &Config{
-		Files:       []string{},
-		Exclude:     []string{"vendor/**", "node_modules/**", ".git/**"},
-		Prompt:      "",
-		MaxFileSize: 1048576, // 1MB
-		Output:      "",       // Empty means clipboard
+		Files:          []string{},
+		Exclude:        []string{"vendor/", "node_modules/", ".git/"},
+		Prompt:         "",
+		MaxFileSize:    1048576, // 1MB
+		Output:         "",      // Empty means clipboard
+		Gitignore:      true,
+		Format:         "text",
+		PromptPosition: "both",
+		XMLContent:     "cdata",
+		DiffContext:    3,
+		Encoding:       "heuristic",
+		SymbolDepth:    1,
+		TestConventions: map[string][]string{
+			"*.go":  {"*_test.go"},
+			"*.js":  {"*.test.js", "*.spec.js", "__tests__/*.js", "__tests__/*.test.js"},
+			"*.jsx": {"*.test.jsx", "*.spec.jsx", "__tests__/*.jsx", "__tests__/*.test.jsx"},
+			"*.ts":  {"*.test.ts", "*.spec.ts", "__tests__/*.ts", "__tests__/*.test.ts"},
+			"*.tsx": {"*.test.tsx", "*.spec.tsx", "__tests__/*.tsx", "__tests__/*.test.tsx"},
+			"*.py":  {"test_*.py", "*_test.py", "tests/test_*.py"},
+		},
 	}
 }
 
@@ -47,9 +93,15 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	// yaml.v3 merges maps into existing ones, so the test conventions of the
+	// file replace the defaults instead, which apply only if the key is absent
+	config.TestConventions = nil
 	if err := yaml.Unmarshal(data, config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if config.TestConventions == nil {
+		config.TestConventions = DefaultConfig().TestConventions
+	}
 
 	return config, nil
 }
@@ -90,4 +142,4 @@ func (c *Config) Merge(files []string, exclude []string, prompt string, output s
 	if maxFileSize > 0 {
 		c.MaxFileSize = maxFileSize
 	}
-}
\ No newline at end of file
+}
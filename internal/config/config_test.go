@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfigTestConventions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string][]string
+	}{
+		{"absent", "tests: both\n", DefaultConfig().TestConventions},
+		{"replaced", "test_conventions:\n  \"*.rb\": [\"*_spec.rb\"]\n", map[string][]string{"*.rb": {"*_spec.rb"}}},
+		{"empty", "test_conventions: {}\n", map[string][]string{}},
+		{"null", "test_conventions:\n", DefaultConfig().TestConventions},
+		// A language that's also a default doesn't bring back the other defaults
+		{"not merged", "test_conventions:\n  \"*.go\": [\"*_test.go\", \"testdata/*.go\"]\n",
+			map[string][]string{"*.go": {"*_test.go", "testdata/*.go"}}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		config, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(config.TestConventions, tt.want) {
+			t.Errorf("%s: test conventions %v, want %v", tt.name, config.TestConventions, tt.want)
+		}
+	}
+}
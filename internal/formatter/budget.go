@@ -0,0 +1,250 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// minWindow is the smallest number of tokens worth keeping of a truncated
+// file; files that would get less are dropped instead
+const minWindow = 64
+
+// maxFitPasses limits how often the budget is tightened when the rendered
+// output still exceeds it
+const maxFitPasses = 4
+
+// Cut records a file that was dropped or truncated to fit the token budget
+type Cut struct {
+	Path    string
+	Dropped bool
+	Removed int // Lines removed from a truncated file
+	Lines   int // Lines in the file before truncation
+}
+
+// SetMaxTokens limits the output to a token budget. Files are truncated or
+// dropped, lowest priority first, until the output fits. Needs a tokenizer.
+func (pf *PromptFormatter) SetMaxTokens(maxTokens int) {
+	pf.maxTokens = maxTokens
+}
+
+// Cuts returns the files cut by the last Format to fit the token budget
+func (pf *PromptFormatter) Cuts() []Cut {
+	return pf.cuts
+}
+
+// docCost is the number of tokens a document adds to the output
+type docCost struct {
+	frame   int // Header and separators around the content
+	content int
+}
+
+// fitBudget picks and truncates documents so the rendered output fits
+// the token budget
+func (pf *PromptFormatter) fitBudget(docs []document) ([]document, error) {
+	base, err := pf.render(nil)
+	if err != nil {
+		return nil, err
+	}
+	overhead := pf.tokenizer.Count(base)
+
+	costs, err := pf.measureDocuments(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := pf.maxTokens
+	for pass := 0; ; pass++ {
+		fitted, cuts := pf.fitDocuments(docs, costs, budget-overhead)
+
+		output, err := pf.render(fitted)
+		if err != nil {
+			return nil, err
+		}
+
+		// Escaping and separators can make the output larger than the sum of its parts
+		excess := pf.tokenizer.Count(output) - pf.maxTokens
+		if excess <= 0 || pass == maxFitPasses-1 {
+			pf.cuts = cuts
+			return fitted, nil
+		}
+		budget -= excess
+	}
+}
+
+// measureDocuments counts the tokens of each document once. Frames are
+// measured without the prompt, tree and diff, so each render is small.
+func (pf *PromptFormatter) measureDocuments(docs []document) ([]docCost, error) {
+	prompt, tree, diff := pf.prompt, pf.tree, pf.diff
+	pf.prompt, pf.tree, pf.diff = "", "", ""
+	defer func() {
+		pf.prompt, pf.tree, pf.diff = prompt, tree, diff
+	}()
+
+	bare, err := pf.render(nil)
+	if err != nil {
+		return nil, err
+	}
+	overhead := pf.tokenizer.Count(bare)
+
+	costs := make([]docCost, len(docs))
+	for i, doc := range docs {
+		empty := doc
+		empty.content = ""
+		framed, err := pf.render([]document{empty})
+		if err != nil {
+			return nil, err
+		}
+		costs[i] = docCost{
+			frame:   pf.tokenizer.Count(framed) - overhead,
+			content: pf.tokenizer.Count(doc.content),
+		}
+	}
+	return costs, nil
+}
+
+// fitDocuments keeps documents in priority order while they fit in budget,
+// truncating the first one that doesn't fit whole. The result keeps the
+// selection order.
+func (pf *PromptFormatter) fitDocuments(docs []document, costs []docCost, budget int) ([]document, []Cut) {
+	kept := make([]*document, len(docs))
+	var cuts []Cut
+
+	for _, i := range priorityOrder(docs) {
+		doc := docs[i]
+		frame, tokens := costs[i].frame, costs[i].content
+
+		available := budget - frame
+		if tokens <= available {
+			kept[i] = &doc
+			budget -= frame + tokens
+			continue
+		}
+
+		if available >= minWindow {
+			if truncated, removed, lines, ok := pf.truncate(doc, available); ok {
+				kept[i] = &truncated
+				budget -= frame + pf.tokenizer.Count(truncated.content)
+				cuts = append(cuts, Cut{Path: doc.header(), Removed: removed, Lines: lines})
+				continue
+			}
+		}
+
+		cuts = append(cuts, Cut{Path: doc.header(), Dropped: true, Lines: countLines(doc.content)})
+	}
+
+	var fitted []document
+	for _, doc := range kept {
+		if doc != nil {
+			fitted = append(fitted, *doc)
+		}
+	}
+
+	// Report cuts in selection order
+	order := make(map[string]int, len(docs))
+	for i, doc := range docs {
+		order[doc.header()] = i
+	}
+	sort.SliceStable(cuts, func(i, j int) bool {
+		return order[cuts[i].Path] < order[cuts[j].Path]
+	})
+
+	return fitted, cuts
+}
+
+// priorityOrder returns the indices of docs from highest to lowest priority:
+// pinned files first, then by the include pattern that matched, then smaller
+// files first
+func priorityOrder(docs []document) []int {
+	order := make([]int, len(docs))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := docs[order[i]].file, docs[order[j]].file
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		if a.Pattern != b.Pattern {
+			return a.Pattern < b.Pattern
+		}
+		return a.Size < b.Size
+	})
+	return order
+}
+
+// truncate keeps the first and last lines of doc that fit in maxTokens,
+// with a marker line in place of the lines in between
+func (pf *PromptFormatter) truncate(doc document, maxTokens int) (document, int, int, bool) {
+	lines := strings.SplitAfter(doc.content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	// window builds the content with keep lines split between head and tail
+	window := func(keep int) string {
+		head, tail := (keep+1)/2, keep/2
+		var result strings.Builder
+		for _, line := range lines[:head] {
+			result.WriteString(line)
+		}
+		result.WriteString(fmt.Sprintf(pf.messages.TruncatedLines+"\n", len(lines)-keep))
+		for _, line := range lines[len(lines)-tail:] {
+			result.WriteString(line)
+		}
+		return result.String()
+	}
+
+	// Find the largest window that fits
+	keep := sort.Search(len(lines), func(keep int) bool {
+		return pf.tokenizer.Count(window(keep)) > maxTokens
+	}) - 1
+	if keep >= 1 {
+		doc.content = window(keep)
+		return doc, len(lines) - keep, len(lines), true
+	}
+
+	// Not even one line fits, as in minified files: keep the start of the first line
+	if content, ok := pf.truncateLine(lines, maxTokens); ok {
+		doc.content = content
+		return doc, len(lines), len(lines), true
+	}
+	return doc, 0, 0, false
+}
+
+// truncateLine keeps the longest start of the first line that fits in
+// maxTokens together with the markers for the cut bytes and lines
+func (pf *PromptFormatter) truncateLine(lines []string, maxTokens int) (string, bool) {
+	first := strings.TrimRight(lines[0], "\r\n")
+	ending := lines[0][len(first):]
+	if ending == "" {
+		ending = "\n"
+	}
+
+	// Cut points at character boundaries
+	var cuts []int
+	for i := range first {
+		cuts = append(cuts, i)
+	}
+
+	// prefix builds the content keeping first[:cuts[n]]
+	prefix := func(n int) string {
+		var result strings.Builder
+		result.WriteString(first[:cuts[n]])
+		result.WriteString(fmt.Sprintf(pf.messages.TruncatedLine, len(first)-cuts[n]))
+		result.WriteString(ending)
+		if len(lines) > 1 {
+			result.WriteString(fmt.Sprintf(pf.messages.TruncatedLines+"\n", len(lines)-1))
+		}
+		return result.String()
+	}
+
+	n := sort.Search(len(cuts), func(n int) bool {
+		return pf.tokenizer.Count(prefix(n)) > maxTokens
+	}) - 1
+	if n < 1 {
+		return "", false
+	}
+	return prefix(n), true
+}
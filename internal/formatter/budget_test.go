@@ -0,0 +1,123 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"aicodeprep-go/internal/selector"
+	"aicodeprep-go/internal/tokenizer"
+)
+
+// newBudgetFormatter creates a text formatter with the heuristic tokenizer and budget
+func newBudgetFormatter(budget int) *PromptFormatter {
+	pf := New("Review this", nil, false)
+	pf.SetTokenizer(tokenizer.NewHeuristic())
+	pf.SetMaxTokens(budget)
+	return pf
+}
+
+// newDocument creates a document for a file with content
+func newDocument(path, content string, pattern int) document {
+	return document{
+		file:    selector.FileInfo{Path: path, Size: int64(len(content)), Pattern: pattern},
+		path:    path,
+		content: content,
+	}
+}
+
+func TestFitBudgetKeepsWhatFits(t *testing.T) {
+	docs := []document{
+		newDocument("a.go", "package a\n", 0),
+		newDocument("b.go", "package b\n", 0),
+	}
+
+	pf := newBudgetFormatter(1000)
+	fitted, err := pf.fitBudget(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fitted) != 2 || len(pf.Cuts()) != 0 {
+		t.Errorf("fitted %d documents with %d cuts, want 2 and 0", len(fitted), len(pf.Cuts()))
+	}
+}
+
+func TestFitBudgetTruncatesLines(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < 500; i++ {
+		content.WriteString("fmt.Println(\"some line of output\")\n")
+	}
+	docs := []document{
+		newDocument("keep.go", "package keep\n", 0),
+		newDocument("long.go", content.String(), 1),
+	}
+
+	pf := newBudgetFormatter(400)
+	fitted, err := pf.fitBudget(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := pf.render(fitted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens := pf.tokenizer.Count(output); tokens > 400 {
+		t.Errorf("output has %d tokens, want at most 400", tokens)
+	}
+
+	cuts := pf.Cuts()
+	if len(fitted) != 2 || len(cuts) != 1 || cuts[0].Path != "long.go" || cuts[0].Dropped {
+		t.Fatalf("fitted %d documents with cuts %+v, want long.go truncated", len(fitted), cuts)
+	}
+	if cuts[0].Lines != 500 || cuts[0].Removed <= 0 || cuts[0].Removed >= 500 {
+		t.Errorf("cut %+v, want part of 500 lines removed", cuts[0])
+	}
+}
+
+func TestFitBudgetTruncatesSingleLine(t *testing.T) {
+	// A minified file: one line far over the budget
+	line := strings.Repeat("var x=function(a,b){return a+b};", 400)
+	docs := []document{newDocument("app.min.js", line, 0)}
+
+	pf := newBudgetFormatter(300)
+	fitted, err := pf.fitBudget(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fitted) != 1 {
+		t.Fatalf("fitted %d documents, want the line truncated instead of dropped", len(fitted))
+	}
+
+	content := fitted[0].content
+	if !strings.HasPrefix(content, "var x=function") || !strings.Contains(content, "… [truncated") {
+		t.Errorf("content = %q, want the start of the line and a marker", content)
+	}
+	output, err := pf.render(fitted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens := pf.tokenizer.Count(output); tokens > 300 {
+		t.Errorf("output has %d tokens, want at most 300", tokens)
+	}
+}
+
+func TestFitBudgetDropsLowPriority(t *testing.T) {
+	docs := []document{
+		newDocument("low.go", strings.Repeat("x", 4000), 1),
+		newDocument("high.go", "package high\n", 0),
+	}
+	docs[0].file.Pinned = false
+	docs[1].file.Pinned = true
+
+	// Too small for a truncated window of low.go
+	pf := newBudgetFormatter(60)
+	fitted, err := pf.fitBudget(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fitted) != 1 || fitted[0].path != "high.go" {
+		t.Errorf("fitted %d documents, want only high.go", len(fitted))
+	}
+	if cuts := pf.Cuts(); len(cuts) != 1 || !cuts[0].Dropped {
+		t.Errorf("cuts = %+v, want low.go dropped", cuts)
+	}
+}
@@ -0,0 +1,135 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// candidate is a legacy encoding tried when a file isn't UTF-8
+type candidate struct {
+	name     string
+	encoding encoding.Encoding
+}
+
+// candidates are tried in order; on equal scores the earlier one wins
+var candidates = []candidate{
+	{"gbk", simplifiedchinese.GBK},
+	{"shift_jis", japanese.ShiftJIS},
+	{"euc-jp", japanese.EUCJP},
+	{"euc-kr", korean.EUCKR},
+	{"big5", traditionalchinese.Big5},
+}
+
+// fallback decodes anything, for files that match no candidate
+var fallback = candidate{"windows-1252", charmap.Windows1252}
+
+// LookupEncoding checks that name is a known encoding label such as
+// "gbk", "shift_jis" or "utf-16le"
+func LookupEncoding(name string) error {
+	_, err := lookupEncoding(name)
+	return err
+}
+
+// lookupEncoding returns the encoding for a WHATWG label
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding '%s'", name)
+	}
+	return enc, nil
+}
+
+// decodeContent converts data to UTF-8 and returns the name of the encoding it
+// was read as. A byte order mark decides first, then a forced encoding; otherwise
+// valid UTF-8 is kept as is and the best scoring legacy encoding wins.
+func decodeContent(data []byte, forced string) (string, string, error) {
+	if forced != "" {
+		enc, err := lookupEncoding(forced)
+		if err != nil {
+			return "", "", err
+		}
+		// A byte order mark still takes precedence and is removed
+		content, _, err := transform.Bytes(xunicode.BOMOverride(enc.NewDecoder()), data)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to decode as %s: %w", forced, err)
+		}
+		return string(content), forced, nil
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		data = data[3:]
+		if utf8.Valid(data) {
+			return string(data), "utf-8", nil
+		}
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeWith(data, "utf-16le", xunicode.UTF16(xunicode.LittleEndian, xunicode.ExpectBOM))
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeWith(data, "utf-16be", xunicode.UTF16(xunicode.BigEndian, xunicode.ExpectBOM))
+	}
+
+	if utf8.Valid(data) {
+		return string(data), "utf-8", nil
+	}
+
+	best, bestScore := fallback, 0
+	var bestContent []byte
+	for _, c := range candidates {
+		content, err := c.encoding.NewDecoder().Bytes(data)
+		if err != nil || bytes.ContainsRune(content, utf8.RuneError) {
+			continue
+		}
+		if score := scoreText(content); bestContent == nil || score > bestScore {
+			best, bestScore, bestContent = c, score, content
+		}
+	}
+
+	if bestContent == nil {
+		return decodeWith(data, fallback.name, fallback.encoding)
+	}
+	return string(bestContent), best.name, nil
+}
+
+// decodeWith decodes data with enc
+func decodeWith(data []byte, name string, enc encoding.Encoding) (string, string, error) {
+	content, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode as %s: %w", name, err)
+	}
+	return string(content), name, nil
+}
+
+// scoreText rates how plausible decoded text is. Kana, Han and Hangul count
+// for it; half-width katakana, private use and other rare characters, which
+// show up when bytes are read in the wrong encoding, count against it.
+func scoreText(content []byte) int {
+	score := 0
+	for _, r := range string(content) {
+		switch {
+		case r < utf8.RuneSelf:
+		case r >= 0xFF61 && r <= 0xFF9F:
+			score--
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			score += 2
+		case unicode.In(r, unicode.Han, unicode.Hangul):
+			score++
+		case unicode.IsLetter(r), unicode.IsPunct(r):
+			// Full-width forms, CJK punctuation, Latin with accents
+		default:
+			score -= 2
+		}
+	}
+	return score
+}
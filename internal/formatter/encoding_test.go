@@ -0,0 +1,61 @@
+package formatter
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// encode converts text to enc for the tests
+func encode(t *testing.T, enc encoding.Encoding, text string) []byte {
+	t.Helper()
+	data, err := enc.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecodeContent(t *testing.T) {
+	const chineseText = "// 计算文件的哈希值并返回结果\nfunc hash() {}\n"
+	const japaneseText = "// ファイルのハッシュを計算します\nfunc hash() {}\n"
+	utf16le := xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM)
+	utf16be := xunicode.UTF16(xunicode.BigEndian, xunicode.IgnoreBOM)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		forced   string
+		want     string
+		encoding string
+	}{
+		{"utf-8", []byte("héllo\n"), "", "héllo\n", "utf-8"},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, "héllo"...), "", "héllo", "utf-8"},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, encode(t, utf16le, "hi 你好")...), "", "hi 你好", "utf-16le"},
+		{"utf-16be bom", append([]byte{0xFE, 0xFF}, encode(t, utf16be, "hi 你好")...), "", "hi 你好", "utf-16be"},
+		{"gbk", encode(t, simplifiedchinese.GBK, chineseText), "", chineseText, "gbk"},
+		{"shift_jis", encode(t, japanese.ShiftJIS, japaneseText), "", japaneseText, "shift_jis"},
+		{"forced gbk", encode(t, simplifiedchinese.GBK, "你好"), "gbk", "你好", "gbk"},
+		{"forced utf-16le", encode(t, utf16le, "abc"), "utf-16le", "abc", "utf-16le"},
+		{"forced with bom", append([]byte{0xFF, 0xFE}, encode(t, utf16le, "abc")...), "gbk", "abc", "gbk"},
+		{"fallback", []byte{'c', 'a', 'f', 0xFF}, "", "cafÿ", "windows-1252"},
+	}
+
+	for _, tt := range tests {
+		content, name, err := decodeContent(tt.data, tt.forced)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if content != tt.want || name != tt.encoding {
+			t.Errorf("%s: decodeContent = %q as %s, want %q as %s", tt.name, content, name, tt.want, tt.encoding)
+		}
+	}
+
+	if _, _, err := decodeContent([]byte("x"), "no-such-encoding"); err == nil {
+		t.Error("decodeContent with an unknown encoding succeeded")
+	}
+}
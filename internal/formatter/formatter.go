@@ -1,58 +1,302 @@
 package formatter
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"text/template"
 	"unicode/utf8"
 
 	"github.com/schollz/progressbar/v3"
 
+	"aicodeprep-go/internal/git"
+	"aicodeprep-go/internal/i18n"
 	"aicodeprep-go/internal/selector"
+	"aicodeprep-go/internal/tokenizer"
+)
+
+// Output formats supported by Format
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatXML      = "xml"
+	FormatJSON     = "json"
+	FormatJSONL    = "jsonl"
+)
+
+// Positions of the user prompt relative to the file contents
+const (
+	PromptTop    = "top"
+	PromptBottom = "bottom"
+	PromptBoth   = "both"
 )
 
 // PromptFormatter formats the prompt with file contents
 type PromptFormatter struct {
-	prompt  string
-	files   []selector.FileInfo
-	verbose bool
+	prompt        string
+	files         []selector.FileInfo
+	verbose       bool
+	format        string
+	position      string
+	xmlCDATA      bool
+	diff          string
+	tree          string // Directory tree shown before the files, empty for none
+	lineNumbers   bool
+	maxLineLength int // Lines longer than this many bytes are truncated, 0 for no limit
+	diffOnly      bool
+	template      *template.Template // Overrides format when set
+	messages      *i18n.Messages
+	tokenizer     tokenizer.Tokenizer // Counts tokens in the summary and verbose output when set
+	sortBy        string
+	maxTokens     int       // Token budget of the output, 0 for no limit
+	cuts          []Cut     // Files cut by the last Format to fit maxTokens
+	partLimit     int       // Size limit of each part in FormatParts, 0 for one part
+	partTokens    bool      // Whether partLimit counts tokens instead of bytes
+	repo          *git.Repo // Opened on first use to read files from a revision
+	symbols       []string  // Go declarations to extract instead of whole files
+	symbolDepth   int       // Levels of referenced declarations added to the symbols
+}
+
+// document is a file whose content has been read and is ready to render
+type document struct {
+	file    selector.FileInfo
+	path    string // Display path, relative to the working directory if possible
+	content string
+	span    string // Line range when the file is split across parts
+	outline bool   // Content is the declarations of the file only
+	note    string // Localized remark shown next to the path
+	line    int    // First line of an extracted declaration, 0 for whole files
 }
 
 // New creates a new PromptFormatter
 func New(prompt string, files []selector.FileInfo, verbose bool) *PromptFormatter {
 	return &PromptFormatter{
-		prompt:  prompt,
-		files:   files,
-		verbose: verbose,
+		prompt:   prompt,
+		files:    files,
+		verbose:  verbose,
+		format:   FormatText,
+		position: PromptBoth,
+		xmlCDATA: true,
+		messages: i18n.English,
 	}
 }
 
+// SetMessages selects the language of the generated prompt and progress output
+func (pf *PromptFormatter) SetMessages(messages *i18n.Messages) {
+	pf.messages = messages
+}
+
+// SetFormat selects the output format, one of the Format* constants
+func (pf *PromptFormatter) SetFormat(format string) {
+	pf.format = format
+}
+
+// SetPromptPosition places the user prompt before the files, after them or both,
+// one of the Prompt* constants
+func (pf *PromptFormatter) SetPromptPosition(position string) {
+	pf.position = position
+}
+
+// SetXMLCDATA chooses between wrapping XML document content in CDATA sections
+// and escaping it as character data
+func (pf *PromptFormatter) SetXMLCDATA(cdata bool) {
+	pf.xmlCDATA = cdata
+}
+
+// SetLineNumbers prefixes each line of the file contents with its number
+func (pf *PromptFormatter) SetLineNumbers(enabled bool) {
+	pf.lineNumbers = enabled
+}
+
+// SetMaxLineLength truncates lines longer than maxLength bytes with a marker
+// instead of including them whole
+func (pf *PromptFormatter) SetMaxLineLength(maxLength int) {
+	pf.maxLineLength = maxLength
+}
+
+// SetDiff adds a unified diff section before the file contents.
+// With diffOnly set, the file contents are left out and only the hunks are emitted.
+func (pf *PromptFormatter) SetDiff(diff string, diffOnly bool) {
+	pf.diff = diff
+	pf.diffOnly = diffOnly
+}
+
 // Format generates the structured prompt text
 func (pf *PromptFormatter) Format() (string, error) {
+	docs, err := pf.documents()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := pf.render(docs)
+	if err != nil {
+		return "", err
+	}
+
+	if pf.verbose && pf.tokenizer != nil {
+		pf.writeTokenReport(os.Stderr, docs, result)
+	}
+
+	return result, nil
+}
+
+// documents loads the documents to render and fits them to the token budget
+func (pf *PromptFormatter) documents() ([]document, error) {
+	switch pf.position {
+	case PromptTop, PromptBottom, PromptBoth:
+	default:
+		return nil, fmt.Errorf("unknown prompt position '%s'", pf.position)
+	}
+
+	var docs []document
+	if len(pf.symbols) > 0 && !pf.diffOnly {
+		symbolDocs, err := pf.symbolDocuments()
+		if err != nil {
+			return nil, err
+		}
+		docs = symbolDocs
+	} else if !pf.diffOnly {
+		docs = pf.loadDocuments()
+	}
+
+	pf.cuts = nil
+	if pf.maxTokens > 0 && pf.tokenizer != nil && len(docs) > 0 {
+		fitted, err := pf.fitBudget(docs)
+		if err != nil {
+			return nil, err
+		}
+		docs = fitted
+	}
+
+	return docs, nil
+}
+
+// render renders docs in the selected format or template
+func (pf *PromptFormatter) render(docs []document) (string, error) {
+	if pf.template != nil {
+		return pf.renderTemplate(docs)
+	}
+
+	switch pf.format {
+	case FormatText, "":
+		return pf.renderText(docs), nil
+	case FormatMarkdown:
+		return pf.renderMarkdown(docs), nil
+	case FormatXML:
+		return pf.renderXML(docs), nil
+	case FormatJSON:
+		return pf.renderJSON(docs)
+	case FormatJSONL:
+		return pf.renderJSONL(docs)
+	default:
+		return "", fmt.Errorf("unknown output format '%s'", pf.format)
+	}
+}
+
+// renderText renders the plain text layout
+func (pf *PromptFormatter) renderText(docs []document) string {
 	var result strings.Builder
 
 	// Add user prompt at the beginning
-	result.WriteString("=== 用户需求 ===\n")
-	if pf.prompt != "" {
-		result.WriteString(pf.prompt)
-	} else {
-		result.WriteString("请分析以下代码文件。")
+	if pf.promptAtTop() {
+		result.WriteString(fmt.Sprintf("=== %s ===\n", pf.messages.PromptHeading))
+		if pf.prompt != "" {
+			result.WriteString(pf.prompt)
+		} else {
+			result.WriteString(pf.messages.DefaultPromptBefore)
+		}
+		result.WriteString("\n\n")
 	}
-	result.WriteString("\n\n")
 
-	// Add file contents section
-	result.WriteString("=== 文件内容开始 ===\n")
+	// Add directory tree
+	if pf.tree != "" {
+		result.WriteString(fmt.Sprintf("=== %s ===\n", pf.messages.TreeHeading))
+		result.WriteString(pf.tree)
+		result.WriteString("\n")
+	}
+
+	// Add diff section
+	if pf.diff != "" {
+		result.WriteString(fmt.Sprintf("=== %s ===\n", pf.messages.DiffBegin))
+		result.WriteString(pf.diff)
+		if !strings.HasSuffix(pf.diff, "\n") {
+			result.WriteString("\n")
+		}
+		result.WriteString(fmt.Sprintf("=== %s ===\n\n", pf.messages.DiffEnd))
+	}
+
+	if !pf.diffOnly {
+		// Add file contents section
+		result.WriteString(fmt.Sprintf("=== %s ===\n", pf.messages.FilesBegin))
+		for _, doc := range docs {
+			result.WriteString(fmt.Sprintf("--- %s ---\n", fmt.Sprintf(pf.messages.FileHeader, doc.header())))
+			result.WriteString(doc.content)
+			if !strings.HasSuffix(doc.content, "\n") {
+				result.WriteString("\n")
+			}
+			result.WriteString("\n")
+		}
+		result.WriteString(fmt.Sprintf("=== %s ===\n\n", pf.messages.FilesEnd))
+	}
+
+	// Add user prompt at the end again
+	if pf.promptAtBottom() {
+		result.WriteString(fmt.Sprintf("=== %s ===\n", pf.messages.PromptHeading))
+		if pf.prompt != "" {
+			result.WriteString(pf.prompt)
+		} else {
+			result.WriteString(pf.messages.DefaultPromptAfter)
+		}
+		result.WriteString("\n")
+	}
+
+	return result.String()
+}
+
+// promptAtTop reports whether the user prompt goes before the files
+func (pf *PromptFormatter) promptAtTop() bool {
+	return pf.position != PromptBottom
+}
+
+// promptAtBottom reports whether the user prompt goes after the files
+func (pf *PromptFormatter) promptAtBottom() bool {
+	return pf.position != PromptTop
+}
+
+// header returns the display path, noting the revision for files read from git,
+// files rendered as declarations only and the line range of a file split
+// across parts
+func (doc document) header() string {
+	header := doc.path
+	if doc.line > 0 {
+		header = fmt.Sprintf("%s:%d", header, doc.line)
+	}
+	if doc.file.Rev != "" {
+		header = fmt.Sprintf("%s @ %s", header, doc.file.Rev)
+	}
+	if doc.note != "" {
+		header = fmt.Sprintf("%s (%s)", header, doc.note)
+	}
+	if doc.span != "" {
+		header = fmt.Sprintf("%s (%s)", header, doc.span)
+	}
+	return header
+}
 
+// loadDocuments reads the selected files, skipping unreadable and empty ones
+func (pf *PromptFormatter) loadDocuments() []document {
+	var docs []document
 	totalSize := int64(0)
-	processedFiles := 0
 
 	// Create progress bar if verbose mode and multiple files
 	var bar *progressbar.ProgressBar
 	if pf.verbose && len(pf.files) > 1 {
 		bar = progressbar.NewOptions(len(pf.files),
-			progressbar.OptionSetDescription("Processing files..."),
+			progressbar.OptionSetDescription(pf.messages.ProcessingFiles),
 			progressbar.OptionSetWriter(os.Stderr),
 			progressbar.OptionShowCount(),
 			progressbar.OptionSetWidth(50),
@@ -70,10 +314,21 @@ func (pf *PromptFormatter) Format() (string, error) {
 			bar.Set(i)
 		}
 
-		content, err := pf.readFileContent(file.Path)
+		// Binary files are listed without their content
+		if file.Binary {
+			path := GetRelativePath(file.Path)
+			docs = append(docs, document{
+				file:    file,
+				path:    path,
+				content: pf.placeholder(file, path),
+			})
+			continue
+		}
+
+		content, outline, err := pf.loadContent(file)
 		if err != nil {
 			if pf.verbose {
-				fmt.Fprintf(os.Stderr, "\nWarning: Failed to read file %s: %v\n", file.Path, err)
+				fmt.Fprintf(os.Stderr, "\n"+pf.messages.FailedToReadFile+"\n", file.Path, err)
 			}
 			continue
 		}
@@ -81,22 +336,23 @@ func (pf *PromptFormatter) Format() (string, error) {
 		// Skip empty files
 		if strings.TrimSpace(content) == "" {
 			if pf.verbose {
-				fmt.Fprintf(os.Stderr, "\nSkipping empty file: %s\n", file.Path)
+				fmt.Fprintf(os.Stderr, "\n"+pf.messages.SkippingEmpty+"\n", file.Path)
 			}
 			continue
 		}
 
-		// Add file header with relative path for better readability
-		displayPath := GetRelativePath(file.Path)
-		result.WriteString(fmt.Sprintf("--- 文件: %s ---\n", displayPath))
-		result.WriteString(content)
-		if !strings.HasSuffix(content, "\n") {
-			result.WriteString("\n")
+		// Use relative path for better readability
+		doc := document{
+			file:    file,
+			path:    GetRelativePath(file.Path),
+			content: content,
+			outline: outline,
 		}
-		result.WriteString("\n")
-
+		if outline {
+			doc.note = pf.messages.SkeletonNote
+		}
+		docs = append(docs, doc)
 		totalSize += file.Size
-		processedFiles++
 	}
 
 	if bar != nil {
@@ -105,85 +361,204 @@ func (pf *PromptFormatter) Format() (string, error) {
 		fmt.Fprintf(os.Stderr, "\n")
 	}
 
-	result.WriteString("=== 文件内容结束 ===\n\n")
-
-	// Add user prompt at the end again
-	result.WriteString("=== 用户需求 ===\n")
-	if pf.prompt != "" {
-		result.WriteString(pf.prompt)
-	} else {
-		result.WriteString("请分析以上代码文件。")
+	if pf.verbose {
+		fmt.Fprintf(os.Stderr, pf.messages.ProcessedFiles+"\n",
+			len(docs), formatBytes(totalSize))
 	}
-	result.WriteString("\n")
 
-	if pf.verbose {
-		fmt.Fprintf(os.Stderr, "Processed %d files, total size: %s\n",
-			processedFiles, formatBytes(totalSize))
+	return docs
+}
+
+// placeholder returns the line that stands in for the content of a binary file
+func (pf *PromptFormatter) placeholder(file selector.FileInfo, path string) string {
+	return fmt.Sprintf(pf.messages.BinaryPlaceholder, path, formatBytes(file.Size), file.MIME) + "\n"
+}
+
+// loadContent reads the content of a file as it appears in the output: as
+// its declarations when it's selected for the skeleton and can be outlined,
+// and otherwise in full with the line length limit and line numbers applied.
+// Outlines aren't numbered, as their lines don't match the file's.
+func (pf *PromptFormatter) loadContent(file selector.FileInfo) (string, bool, error) {
+	content, err := pf.readFileContent(file)
+	if err != nil {
+		return "", false, err
 	}
 
-	return result.String(), nil
+	if file.Skeleton && canOutline(file.Path) {
+		outline, err := goSkeleton(content)
+		if err == nil {
+			return pf.processLines(outline, 0), true, nil
+		}
+		if pf.verbose {
+			fmt.Fprintf(os.Stderr, "\n"+pf.messages.SkeletonFailed+"\n", file.Path, err)
+		}
+	}
+	firstLine := 0
+	if pf.lineNumbers {
+		firstLine = 1
+	}
+	return pf.processLines(content, firstLine), false, nil
 }
 
 // readFileContent reads and validates file content
-func (pf *PromptFormatter) readFileContent(filePath string) (string, error) {
-	file, err := os.Open(filePath)
+func (pf *PromptFormatter) readFileContent(fileInfo selector.FileInfo) (string, error) {
+	if fileInfo.Rev != "" {
+		return pf.readRevisionContent(fileInfo)
+	}
+
+	file, err := os.Open(fileInfo.Path)
 	if err != nil {
 		return "", fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
+	return pf.readContent(file, fileInfo)
+}
+
+// readRevisionContent reads file content from the git object store
+func (pf *PromptFormatter) readRevisionContent(fileInfo selector.FileInfo) (string, error) {
+	if pf.repo == nil {
+		repo, err := git.Open(filepath.Dir(fileInfo.Path))
+		if err != nil {
+			// The directory may not exist in the working tree
+			repo, err = git.Open(".")
+			if err != nil {
+				return "", err
+			}
+		}
+		pf.repo = repo
+	}
+
+	relPath, err := filepath.Rel(pf.repo.Root, fileInfo.Path)
+	if err != nil {
+		return "", fmt.Errorf("file is outside the repository: %w", err)
+	}
+
+	data, err := pf.repo.ReadFile(fileInfo.Rev, filepath.ToSlash(relPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to read file at %s: %w", fileInfo.Rev, err)
+	}
+
+	return pf.readContent(bytes.NewReader(data), fileInfo)
+}
+
+// readContent reads the content of fileInfo from r and converts it to UTF-8
+func (pf *PromptFormatter) readContent(r io.Reader, fileInfo selector.FileInfo) (string, error) {
 	// Read file content
-	content, err := readFileAsString(file)
+	data, err := io.ReadAll(r)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file content: %w", err)
 	}
 
-	// Validate UTF-8 encoding
-	if !utf8.ValidString(content) {
-		return "", fmt.Errorf("file contains invalid UTF-8 encoding")
+	// Transcode legacy encodings
+	content, encoding, err := decodeContent(data, fileInfo.Encoding)
+	if err != nil {
+		return "", err
+	}
+	if pf.verbose && encoding != "utf-8" {
+		fmt.Fprintf(os.Stderr, "\n"+pf.messages.Transcoded+"\n", fileInfo.Path, encoding)
 	}
 
 	return content, nil
 }
 
-// readFileAsString reads file content and handles different line endings
-func readFileAsString(file *os.File) (string, error) {
-	var result strings.Builder
-	scanner := bufio.NewScanner(file)
+// processLines truncates lines longer than the line length limit and, unless
+// firstLine is 0, numbers the lines from firstLine in a gutter as wide as the
+// largest number. Line endings, including a missing one on the last line, are
+// kept as they are.
+func (pf *PromptFormatter) processLines(content string, firstLine int) string {
+	return rewriteLines(content, firstLine, pf.maxLineLength, pf.messages.TruncatedLine)
+}
 
-	lineNumber := 1
-	for scanner.Scan() {
-		line := scanner.Text()
-		result.WriteString(line)
-		result.WriteString("\n")
-		lineNumber++
+// rewriteLines is processLines with the limit, 0 for none, and the
+// truncation marker given
+func rewriteLines(content string, firstLine, maxLineLength int, truncated string) string {
+	if maxLineLength <= 0 && firstLine == 0 {
+		return content
 	}
 
-	if err := scanner.Err(); err != nil {
-		return "", err
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
 	}
 
-	return result.String(), nil
+	// Blank files stay blank so they are still skipped as empty
+	lineNumbers := firstLine > 0 && strings.TrimSpace(content) != ""
+	width := len(strconv.Itoa(firstLine + len(lines) - 1))
+
+	var result strings.Builder
+	for i, line := range lines {
+		if lineNumbers {
+			result.WriteString(fmt.Sprintf("%*d | ", width, firstLine+i))
+		}
+
+		text := strings.TrimRight(line, "\r\n")
+		ending := line[len(text):]
+		if maxLineLength > 0 && len(text) > maxLineLength {
+			// Cut at a character boundary
+			cut := maxLineLength
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+			result.WriteString(text[:cut])
+			result.WriteString(fmt.Sprintf(truncated, len(text)-cut))
+		} else {
+			result.WriteString(text)
+		}
+		result.WriteString(ending)
+	}
+
+	return result.String()
 }
 
 // GetSummary returns a summary of what will be processed
 func (pf *PromptFormatter) GetSummary() string {
 	var result strings.Builder
 
-	result.WriteString("Files to be processed:\n")
+	result.WriteString(pf.messages.SummaryTitle + "\n")
 
+	entries := make([]fileTokens, 0, len(pf.files))
 	totalSize := int64(0)
-	for i, file := range pf.files {
-		result.WriteString(fmt.Sprintf("%d. %s (%s)\n",
-			i+1, file.Path, formatBytes(file.Size)))
+	totalTokens := 0
+	for _, file := range pf.files {
+		entry := fileTokens{file: file, path: file.Path}
+		if file.Rev != "" {
+			entry.path = fmt.Sprintf("%s @ %s", entry.path, file.Rev)
+		}
+		if pf.tokenizer != nil {
+			// Unreadable files count as no tokens, as they're skipped in the output
+			if file.Binary {
+				entry.tokens = pf.tokenizer.Count(pf.placeholder(file, GetRelativePath(file.Path)))
+			} else if content, _, err := pf.loadContent(file); err == nil {
+				entry.tokens = pf.tokenizer.Count(content)
+			}
+		}
+		entries = append(entries, entry)
 		totalSize += file.Size
+		totalTokens += entry.tokens
+	}
+	pf.sortReport(entries)
+
+	for i, entry := range entries {
+		if pf.tokenizer != nil {
+			result.WriteString(fmt.Sprintf(pf.messages.SummaryFileTokens+"\n",
+				i+1, entry.path, formatBytes(entry.file.Size), entry.tokens))
+		} else {
+			result.WriteString(fmt.Sprintf("%d. %s (%s)\n",
+				i+1, entry.path, formatBytes(entry.file.Size)))
+		}
 	}
 
-	result.WriteString(fmt.Sprintf("\nTotal: %d files, %s\n",
-		len(pf.files), formatBytes(totalSize)))
+	if pf.tokenizer != nil {
+		result.WriteString("\n" + fmt.Sprintf(pf.messages.SummaryTotalTokens,
+			len(pf.files), formatBytes(totalSize), totalTokens, pf.tokenizer.Name()) + "\n")
+	} else {
+		result.WriteString("\n" + fmt.Sprintf(pf.messages.SummaryTotal,
+			len(pf.files), formatBytes(totalSize)) + "\n")
+	}
 
 	if pf.prompt != "" {
-		result.WriteString(fmt.Sprintf("\nPrompt: %s\n", pf.prompt))
+		result.WriteString("\n" + fmt.Sprintf(pf.messages.SummaryPrompt, pf.prompt) + "\n")
 	}
 
 	return result.String()
@@ -208,6 +583,12 @@ func ValidateFiles(files []selector.FileInfo) []selector.FileInfo {
 	var validFiles []selector.FileInfo
 
 	for _, file := range files {
+		// Files from a revision are read from the object store
+		if file.Rev != "" {
+			validFiles = append(validFiles, file)
+			continue
+		}
+
 		if info, err := os.Stat(file.Path); err == nil && info.Mode().IsRegular() {
 			// Check if file is readable
 			if f, err := os.Open(file.Path); err == nil {
@@ -231,4 +612,4 @@ func GetRelativePath(path string) string {
 		}
 	}
 	return path
-}
\ No newline at end of file
+}
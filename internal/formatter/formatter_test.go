@@ -0,0 +1,134 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"aicodeprep-go/internal/selector"
+)
+
+// numbered returns count lines "x" numbered from 1 in a gutter of width
+func numbered(count, width int) string {
+	var result strings.Builder
+	for i := 1; i <= count; i++ {
+		fmt.Fprintf(&result, "%*d | x\n", width, i)
+	}
+	return result.String()
+}
+
+func TestProcessLinesNumbers(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		firstLine int
+		want      string
+	}{
+		{"no numbers", "a\nb\n", 0, "a\nb\n"},
+		{"nine lines", strings.Repeat("x\n", 9), 1, numbered(9, 1)},
+		{"ten lines", strings.Repeat("x\n", 10), 1, numbered(10, 2)},
+		{"declaration part", "a\nb\n", 120, "120 | a\n121 | b\n"},
+		{"declaration across a digit boundary", "a\nb\n", 99, " 99 | a\n100 | b\n"},
+		{"blank file", "\n\n", 1, "\n\n"},
+	}
+
+	pf := New("", nil, false)
+	for _, tt := range tests {
+		if got := pf.processLines(tt.content, tt.firstLine); got != tt.want {
+			t.Errorf("%s: processLines =\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProcessLinesByteExact(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		firstLine     int
+		maxLineLength int
+		want          string
+	}{
+		{"crlf", "a\r\nb\r\n", 0, 80, "a\r\nb\r\n"},
+		{"crlf numbered", "a\r\nb\r\n", 1, 0, "1 | a\r\n2 | b\r\n"},
+		{"mixed endings", "a\r\nb\nc\r", 0, 80, "a\r\nb\nc\r"},
+		{"no trailing newline", "a\nb", 0, 80, "a\nb"},
+		{"no trailing newline numbered", "a\nb", 1, 0, "1 | a\n2 | b"},
+		{"ascii cut", "abcdef\n", 0, 4, "abcd… [truncated 2 bytes]\n"},
+		{"cut before a character", "abcé\n", 0, 4, "abc… [truncated 2 bytes]\n"},
+		{"cut after a character", "aé日本\n", 0, 4, "aé… [truncated 6 bytes]\n"},
+		{"cut keeps crlf", "abcdef\r\n", 0, 4, "abcd… [truncated 2 bytes]\r\n"},
+		{"cut without newline", "abcdef", 1, 4, "1 | abcd… [truncated 2 bytes]"},
+		{"line at the limit", "abcd\n", 0, 4, "abcd\n"},
+	}
+
+	for _, tt := range tests {
+		pf := New("", nil, false)
+		pf.SetMaxLineLength(tt.maxLineLength)
+		if got := pf.processLines(tt.content, tt.firstLine); got != tt.want {
+			t.Errorf("%s: processLines = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadRevisionContent(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip(err)
+	}
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "test")
+	write("main.go", "package main\n")
+	write("gone/old.go", "package gone\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+
+	// The working tree no longer matches the revision
+	write("main.go", "package main\n\nfunc main() {}\n")
+	if err := os.RemoveAll(filepath.Join(root, "gone")); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+
+	tests := []struct {
+		rel  string
+		want string
+	}{
+		{"main.go", "package main\n"},
+		{"gone/old.go", "package gone\n"},
+	}
+	for _, tt := range tests {
+		pf := New("", nil, false)
+		file := selector.FileInfo{Path: filepath.Join(root, filepath.FromSlash(tt.rel)), Rev: "HEAD"}
+		got, err := pf.readFileContent(file)
+		if err != nil {
+			t.Errorf("%s: %v", tt.rel, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: read %q, want %q", tt.rel, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package formatter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// jsonBundle is the document emitted by the json format
+type jsonBundle struct {
+	Prompt     string     `json:"prompt"`
+	Tree       string     `json:"tree,omitempty"`
+	Diff       string     `json:"diff,omitempty"`
+	Files      []jsonFile `json:"files"`
+	TotalFiles int        `json:"total_files"`
+	TotalSize  int64      `json:"total_size"`
+}
+
+// jsonFile describes one file in the json and jsonl formats
+type jsonFile struct {
+	Type     string `json:"type,omitempty"` // Only set in jsonl records
+	Path     string `json:"path"`
+	Revision string `json:"revision,omitempty"`
+	Range    string `json:"range,omitempty"` // Line range of a file split across parts
+	Line     int    `json:"line,omitempty"`  // First line of an extracted declaration
+	Size     int64  `json:"size"`            // Bytes of Content
+	Lines    int    `json:"lines"`           // Lines of Content
+	Language string `json:"language"`
+	Binary   bool   `json:"binary,omitempty"` // Content is a placeholder
+	MIME     string `json:"mime,omitempty"`
+	Skeleton bool   `json:"skeleton,omitempty"` // Content is the declarations only
+	SHA256   string `json:"sha256"`             // Hash of Content
+	Content  string `json:"content"`
+}
+
+// jsonPromptRecord is the first line of the jsonl format
+type jsonPromptRecord struct {
+	Type   string `json:"type"`
+	Prompt string `json:"prompt"`
+}
+
+// jsonTreeRecord carries the directory tree in the jsonl format
+type jsonTreeRecord struct {
+	Type string `json:"type"`
+	Tree string `json:"tree"`
+}
+
+// jsonDiffRecord carries the diff in the jsonl format
+type jsonDiffRecord struct {
+	Type string `json:"type"`
+	Diff string `json:"diff"`
+}
+
+// newJSONFile builds the json description of a document. Size, lines and hash
+// describe the emitted content, which differs from the file on disk after
+// transcoding, line numbering, truncation or declaration extraction.
+func newJSONFile(doc document) jsonFile {
+	sum := sha256.Sum256([]byte(doc.content))
+	return jsonFile{
+		Path:     doc.path,
+		Revision: doc.file.Rev,
+		Range:    doc.span,
+		Line:     doc.line,
+		Size:     int64(len(doc.content)),
+		Lines:    countLines(doc.content),
+		Language: detectLanguage(doc.path),
+		Binary:   doc.file.Binary,
+		MIME:     doc.file.MIME,
+		Skeleton: doc.outline,
+		SHA256:   hex.EncodeToString(sum[:]),
+		Content:  doc.content,
+	}
+}
+
+// renderJSON renders the bundle as a single indented JSON document
+func (pf *PromptFormatter) renderJSON(docs []document) (string, error) {
+	bundle := jsonBundle{
+		Prompt: pf.prompt,
+		Tree:   pf.tree,
+		Diff:   pf.diff,
+		Files:  []jsonFile{},
+	}
+	for _, doc := range docs {
+		file := newJSONFile(doc)
+		bundle.Files = append(bundle.Files, file)
+		bundle.TotalSize += file.Size
+	}
+	bundle.TotalFiles = len(bundle.Files)
+
+	var result strings.Builder
+	encoder := newJSONEncoder(&result)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(bundle); err != nil {
+		return "", fmt.Errorf("failed to encode json: %w", err)
+	}
+	return result.String(), nil
+}
+
+// renderJSONL renders the bundle as one JSON object per line: the prompt,
+// the directory tree and the diff if there are any, then one record per file
+func (pf *PromptFormatter) renderJSONL(docs []document) (string, error) {
+	var result strings.Builder
+	encoder := newJSONEncoder(&result)
+
+	if err := encoder.Encode(jsonPromptRecord{Type: "prompt", Prompt: pf.prompt}); err != nil {
+		return "", fmt.Errorf("failed to encode json: %w", err)
+	}
+
+	if pf.tree != "" {
+		if err := encoder.Encode(jsonTreeRecord{Type: "tree", Tree: pf.tree}); err != nil {
+			return "", fmt.Errorf("failed to encode json: %w", err)
+		}
+	}
+
+	if pf.diff != "" {
+		if err := encoder.Encode(jsonDiffRecord{Type: "diff", Diff: pf.diff}); err != nil {
+			return "", fmt.Errorf("failed to encode json: %w", err)
+		}
+	}
+
+	for _, doc := range docs {
+		file := newJSONFile(doc)
+		file.Type = "file"
+		if err := encoder.Encode(file); err != nil {
+			return "", fmt.Errorf("failed to encode json: %w", err)
+		}
+	}
+
+	return result.String(), nil
+}
+
+// newJSONEncoder creates an encoder that leaves <, > and & in source code readable
+func newJSONEncoder(w io.Writer) *json.Encoder {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder
+}
+
+// countLines counts the lines in content, including a last line without a newline
+func countLines(content string) int {
+	lines := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	return lines
+}
@@ -0,0 +1,31 @@
+package formatter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"aicodeprep-go/internal/selector"
+)
+
+func TestNewJSONFileDescribesContent(t *testing.T) {
+	// The file on disk is larger than the emitted declaration
+	doc := document{
+		file:    selector.FileInfo{Path: "p.go", Size: 4096},
+		path:    "p.go",
+		content: "func F() {}\n",
+		line:    120,
+	}
+
+	file := newJSONFile(doc)
+	sum := sha256.Sum256([]byte(doc.content))
+	if file.Size != int64(len(doc.content)) {
+		t.Errorf("size = %d, want %d", file.Size, len(doc.content))
+	}
+	if file.Lines != 1 {
+		t.Errorf("lines = %d, want 1", file.Lines)
+	}
+	if file.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("sha256 = %s, want the hash of the content", file.SHA256)
+	}
+}
@@ -0,0 +1,128 @@
+package formatter
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// languagesByExtension maps file extensions to Markdown info strings
+var languagesByExtension = map[string]string{
+	".go":         "go",
+	".mod":        "go",
+	".py":         "python",
+	".pyi":        "python",
+	".js":         "javascript",
+	".mjs":        "javascript",
+	".cjs":        "javascript",
+	".jsx":        "jsx",
+	".ts":         "typescript",
+	".mts":        "typescript",
+	".cts":        "typescript",
+	".tsx":        "tsx",
+	".vue":        "vue",
+	".svelte":     "svelte",
+	".java":       "java",
+	".kt":         "kotlin",
+	".kts":        "kotlin",
+	".scala":      "scala",
+	".groovy":     "groovy",
+	".gradle":     "groovy",
+	".c":          "c",
+	".h":          "c",
+	".cc":         "cpp",
+	".cpp":        "cpp",
+	".cxx":        "cpp",
+	".hh":         "cpp",
+	".hpp":        "cpp",
+	".cs":         "csharp",
+	".fs":         "fsharp",
+	".rs":         "rust",
+	".swift":      "swift",
+	".m":          "objectivec",
+	".mm":         "objectivec",
+	".rb":         "ruby",
+	".php":        "php",
+	".pl":         "perl",
+	".pm":         "perl",
+	".lua":        "lua",
+	".r":          "r",
+	".dart":       "dart",
+	".ex":         "elixir",
+	".exs":        "elixir",
+	".erl":        "erlang",
+	".hs":         "haskell",
+	".clj":        "clojure",
+	".ml":         "ocaml",
+	".zig":        "zig",
+	".sh":         "bash",
+	".bash":       "bash",
+	".zsh":        "zsh",
+	".fish":       "fish",
+	".ps1":        "powershell",
+	".bat":        "batch",
+	".cmd":        "batch",
+	".sql":        "sql",
+	".html":       "html",
+	".htm":        "html",
+	".xml":        "xml",
+	".svg":        "xml",
+	".css":        "css",
+	".scss":       "scss",
+	".sass":       "sass",
+	".less":       "less",
+	".json":       "json",
+	".jsonc":      "jsonc",
+	".yaml":       "yaml",
+	".yml":        "yaml",
+	".toml":       "toml",
+	".ini":        "ini",
+	".cfg":        "ini",
+	".conf":       "ini",
+	".properties": "properties",
+	".md":         "markdown",
+	".markdown":   "markdown",
+	".rst":        "rst",
+	".tex":        "latex",
+	".proto":      "protobuf",
+	".graphql":    "graphql",
+	".gql":        "graphql",
+	".tf":         "hcl",
+	".hcl":        "hcl",
+	".diff":       "diff",
+	".patch":      "diff",
+	".cmake":      "cmake",
+	".nix":        "nix",
+	".txt":        "text",
+}
+
+// languagesByName maps well-known file names without a useful extension
+var languagesByName = map[string]string{
+	"makefile":       "makefile",
+	"gnumakefile":    "makefile",
+	"dockerfile":     "dockerfile",
+	"containerfile":  "dockerfile",
+	"cmakelists.txt": "cmake",
+	"go.sum":         "text",
+	"gemfile":        "ruby",
+	"rakefile":       "ruby",
+	"jenkinsfile":    "groovy",
+	"vagrantfile":    "ruby",
+	".bashrc":        "bash",
+	".zshrc":         "zsh",
+	".gitignore":     "gitignore",
+	".dockerignore":  "gitignore",
+	".editorconfig":  "ini",
+}
+
+// detectLanguage returns the language of a file derived from its name,
+// or an empty string if it is unknown
+func detectLanguage(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	if language, ok := languagesByName[name]; ok {
+		return language
+	}
+	if strings.HasPrefix(name, "dockerfile.") {
+		return "dockerfile"
+	}
+	return languagesByExtension[filepath.Ext(name)]
+}
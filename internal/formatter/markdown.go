@@ -0,0 +1,105 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderMarkdown renders each file as a fenced code block under a heading
+func (pf *PromptFormatter) renderMarkdown(docs []document) string {
+	var result strings.Builder
+
+	// Add user prompt at the beginning
+	if pf.promptAtTop() {
+		result.WriteString(fmt.Sprintf("## %s\n\n", pf.messages.PromptHeading))
+		if pf.prompt != "" {
+			result.WriteString(pf.prompt)
+		} else {
+			result.WriteString(pf.messages.DefaultPromptBefore)
+		}
+		result.WriteString("\n\n")
+	}
+
+	// Add directory tree
+	if pf.tree != "" {
+		result.WriteString(fmt.Sprintf("## %s\n\n", pf.messages.TreeHeading))
+		writeFenced(&result, pf.tree, "")
+		result.WriteString("\n")
+	}
+
+	// Add diff section
+	if pf.diff != "" {
+		result.WriteString(fmt.Sprintf("## %s\n\n", pf.messages.DiffHeading))
+		writeFenced(&result, pf.diff, "diff")
+		result.WriteString("\n")
+	}
+
+	if !pf.diffOnly {
+		result.WriteString(fmt.Sprintf("## %s\n\n", pf.messages.FilesHeading))
+		for _, doc := range docs {
+			result.WriteString(fmt.Sprintf("### %s\n\n", inlineCode(doc.header())))
+			writeFenced(&result, doc.content, detectLanguage(doc.path))
+			result.WriteString("\n")
+		}
+	}
+
+	// Add user prompt at the end again
+	if pf.promptAtBottom() {
+		result.WriteString(fmt.Sprintf("## %s\n\n", pf.messages.PromptHeading))
+		if pf.prompt != "" {
+			result.WriteString(pf.prompt)
+		} else {
+			result.WriteString(pf.messages.DefaultPromptAfter)
+		}
+		result.WriteString("\n")
+	}
+
+	return result.String()
+}
+
+// writeFenced writes content as a fenced code block with the given info string
+func writeFenced(result *strings.Builder, content, info string) {
+	fence := codeFence(content)
+	result.WriteString(fence)
+	result.WriteString(info)
+	result.WriteString("\n")
+	result.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		result.WriteString("\n")
+	}
+	result.WriteString(fence)
+	result.WriteString("\n")
+}
+
+// codeFence returns a backtick fence longer than any backtick run in content,
+// so the content can't close the block early
+func codeFence(content string) string {
+	return strings.Repeat("`", max(3, longestBacktickRun(content)+1))
+}
+
+// inlineCode wraps text in a code span delimited by more backticks than any
+// run in text. Text starting or ending with a backtick is padded with a space,
+// which Markdown strips from the span.
+func inlineCode(text string) string {
+	delimiter := strings.Repeat("`", longestBacktickRun(text)+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return delimiter + text + delimiter
+}
+
+// longestBacktickRun returns the length of the longest run of backticks in s
+func longestBacktickRun(s string) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
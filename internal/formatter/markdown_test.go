@@ -0,0 +1,40 @@
+package formatter
+
+import "testing"
+
+func TestInlineCode(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"main.go", "`main.go`"},
+		{"a`b.go", "``a`b.go``"},
+		{"a``b`c.go", "```a``b`c.go```"},
+		{"`odd.go", "`` `odd.go ``"},
+		{"odd.go`", "`` odd.go` ``"},
+	}
+
+	for _, tt := range tests {
+		if got := inlineCode(tt.text); got != tt.want {
+			t.Errorf("inlineCode(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCodeFence(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"plain", "```"},
+		{"a `b` c", "```"},
+		{"```go\n```", "````"},
+		{"`````", "``````"},
+	}
+
+	for _, tt := range tests {
+		if got := codeFence(tt.content); got != tt.want {
+			t.Errorf("codeFence(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
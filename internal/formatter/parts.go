@@ -0,0 +1,304 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SetPartLimit makes FormatParts split the output into parts of at most limit
+// bytes, or tokens if tokens is set. Counting tokens needs a tokenizer.
+func (pf *PromptFormatter) SetPartLimit(limit int, tokens bool) {
+	pf.partLimit = limit
+	pf.partTokens = tokens
+}
+
+// FormatParts generates the prompt split at file boundaries into numbered
+// parts under the part limit. Each part tells the model which part it is;
+// the user prompt comes with the last part. Files too large for one part
+// are split by lines. Output within the limit is a single unnumbered part.
+func (pf *PromptFormatter) FormatParts() ([]string, error) {
+	if pf.partLimit <= 0 {
+		result, err := pf.Format()
+		if err != nil {
+			return nil, err
+		}
+		return []string{result}, nil
+	}
+
+	docs, err := pf.documents()
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := pf.renderParts(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	if pf.verbose && pf.tokenizer != nil {
+		pf.writeTokenReport(os.Stderr, docs, strings.Join(parts, ""))
+	}
+
+	return parts, nil
+}
+
+// measure returns the size of s in the unit of the part limit
+func (pf *PromptFormatter) measure(s string) int {
+	if pf.partTokens {
+		return pf.tokenizer.Count(s)
+	}
+	return len(s)
+}
+
+// partPrompt returns the prompt of part i of n
+func (pf *PromptFormatter) partPrompt(prompt string, i, n int) string {
+	if i < n {
+		return fmt.Sprintf(pf.messages.PartNote, i, n)
+	}
+
+	if prompt == "" {
+		prompt = pf.messages.DefaultPromptAfter
+	}
+	return fmt.Sprintf(pf.messages.PartNoteLast, i, n) + "\n\n" + prompt
+}
+
+// renderParts packs docs into parts and renders each of them. Output that
+// fits the part limit as a whole is rendered as by Format, without part notes.
+func (pf *PromptFormatter) renderParts(docs []document) ([]string, error) {
+	whole, err := pf.render(docs)
+	if err != nil {
+		return nil, err
+	}
+	if pf.measure(whole) <= pf.partLimit {
+		return []string{whole}, nil
+	}
+
+	// The part notes grow with the digits of the part count, so pack for a
+	// count of one digit and add digits until the parts are numbered by it
+	for digits, count := 1, 9; digits <= maxPartDigits; digits, count = digits+1, count*10+9 {
+		parts, err := pf.packParts(docs, count)
+		if err != nil {
+			return nil, err
+		}
+		if parts != nil {
+			return parts, nil
+		}
+	}
+	return nil, fmt.Errorf("part limit %d needs too many parts", pf.partLimit)
+}
+
+// maxPartDigits bounds the digits of the part count
+const maxPartDigits = 9
+
+// packParts packs docs into at most count parts, leaving room for the notes
+// of that many parts, and renders each of them. It returns nil parts if more
+// are needed or a rendered part ends up over the limit.
+func (pf *PromptFormatter) packParts(docs []document, count int) ([]string, error) {
+	prompt, diff, tree := pf.prompt, pf.diff, pf.tree
+	defer func() {
+		pf.prompt, pf.diff, pf.tree = prompt, diff, tree
+	}()
+
+	// Overhead of a part without files, assuming the longer of the notes of
+	// a middle part and of the last part. Documents are measured with it.
+	longest := pf.partPrompt(prompt, count-1, count)
+	if last := pf.partPrompt(prompt, count, count); pf.measure(last) > pf.measure(longest) {
+		longest = last
+	}
+	pf.prompt = longest
+	first, err := pf.render(nil)
+	if err != nil {
+		return nil, err
+	}
+	pf.diff, pf.tree = "", ""
+	empty, err := pf.render(nil)
+	if err != nil {
+		return nil, err
+	}
+	firstOverhead, overhead := pf.measure(first), pf.measure(empty)
+
+	capacity := pf.partLimit - overhead
+	if capacity <= 0 || firstOverhead > pf.partLimit {
+		return nil, fmt.Errorf("part limit %d is too small for the prompt, tree and diff", pf.partLimit)
+	}
+
+	// Split files that don't fit in a part on their own
+	var pieces []document
+	var costs []int
+	for _, doc := range docs {
+		cost, err := pf.documentCost(doc, overhead)
+		if err != nil {
+			return nil, err
+		}
+		if cost <= capacity {
+			pieces = append(pieces, doc)
+			costs = append(costs, cost)
+			continue
+		}
+
+		chunks, err := pf.splitDocument(doc, capacity, overhead)
+		if err != nil {
+			return nil, err
+		}
+		for _, chunk := range chunks {
+			cost, err := pf.documentCost(chunk, overhead)
+			if err != nil {
+				return nil, err
+			}
+			pieces = append(pieces, chunk)
+			costs = append(costs, cost)
+		}
+	}
+
+	// Fill parts in order, starting a new part when the next piece doesn't fit.
+	// The first part may be left with only the tree and diff.
+	var groups [][]document
+	var group []document
+	used := firstOverhead
+	for i, piece := range pieces {
+		if (len(group) > 0 || used > overhead) && used+costs[i] > pf.partLimit {
+			groups = append(groups, group)
+			group, used = nil, overhead
+		}
+		group = append(group, piece)
+		used += costs[i]
+	}
+	groups = append(groups, group)
+	if len(groups) > count {
+		return nil, nil
+	}
+
+	parts := make([]string, len(groups))
+	for i, group := range groups {
+		pf.prompt = pf.partPrompt(prompt, i+1, len(groups))
+		pf.diff, pf.tree = "", ""
+		if i == 0 {
+			pf.diff, pf.tree = diff, tree
+		}
+
+		parts[i], err = pf.render(group)
+		if err != nil {
+			return nil, err
+		}
+
+		// Token counts of the notes don't strictly grow with their numbers
+		if pf.measure(parts[i]) > pf.partLimit {
+			return nil, nil
+		}
+	}
+	return parts, nil
+}
+
+// documentCost returns how much doc adds to a part with the given overhead
+func (pf *PromptFormatter) documentCost(doc document, overhead int) (int, error) {
+	rendered, err := pf.render([]document{doc})
+	if err != nil {
+		return 0, err
+	}
+	return pf.measure(rendered) - overhead, nil
+}
+
+// splitDocument splits doc by lines into chunks that each fit in capacity.
+// Lines longer than a chunk are split at character boundaries.
+func (pf *PromptFormatter) splitDocument(doc document, capacity, overhead int) ([]document, error) {
+	lines := strings.SplitAfter(doc.content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	// Room for content once the header of the chunk is paid for
+	frame := pf.chunk(doc, "", len(lines), len(lines))
+	frameCost, err := pf.documentCost(frame, overhead)
+	if err != nil {
+		return nil, err
+	}
+	room := capacity - frameCost
+
+	// Escaping can make a rendered chunk larger than its content, so shrink
+	// the room by the excess until every chunk fits
+	for room > 0 {
+		chunks, ok := pf.packLines(doc, lines, room)
+		if !ok {
+			break
+		}
+
+		excess := 0
+		for _, chunk := range chunks {
+			cost, err := pf.documentCost(chunk, overhead)
+			if err != nil {
+				return nil, err
+			}
+			excess = max(excess, cost-capacity)
+		}
+		if excess <= 0 {
+			return chunks, nil
+		}
+		room -= excess
+	}
+	return nil, fmt.Errorf("part limit %d is too small for %s", pf.partLimit, doc.header())
+}
+
+// packLines packs lines into chunks whose content measures at most room.
+// It returns false if room can't hold a single character.
+func (pf *PromptFormatter) packLines(doc document, lines []string, room int) ([]document, bool) {
+	var chunks []document
+	var content strings.Builder
+	first, last, size := 1, 1, 0
+	for i, line := range lines {
+		pieces, ok := pf.splitLine(line, room)
+		if !ok {
+			return nil, false
+		}
+		for _, piece := range pieces {
+			pieceSize := pf.measure(piece)
+			if content.Len() > 0 && size+pieceSize > room {
+				chunks = append(chunks, pf.chunk(doc, content.String(), first, last))
+				content.Reset()
+				size = 0
+			}
+			if content.Len() == 0 {
+				first = i + 1
+			}
+			content.WriteString(piece)
+			size += pieceSize
+			last = i + 1
+		}
+	}
+	return append(chunks, pf.chunk(doc, content.String(), first, last)), true
+}
+
+// splitLine splits line into pieces that each measure at most room, cutting
+// at character boundaries. It returns false if a character doesn't fit.
+func (pf *PromptFormatter) splitLine(line string, room int) ([]string, bool) {
+	var pieces []string
+	for pf.measure(line) > room {
+		// Longest prefix that fits
+		cut := sort.Search(len(line)+1, func(n int) bool {
+			return pf.measure(line[:n]) > room
+		}) - 1
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		if cut <= 0 {
+			return nil, false
+		}
+		pieces = append(pieces, line[:cut])
+		line = line[cut:]
+	}
+	return append(pieces, line), true
+}
+
+// chunk returns content, lines first to last of doc, as a document of its own.
+// The span of an extracted declaration counts lines of its file.
+func (pf *PromptFormatter) chunk(doc document, content string, first, last int) document {
+	if doc.line > 0 {
+		first += doc.line - 1
+		last += doc.line - 1
+	}
+	doc.content = content
+	doc.span = fmt.Sprintf(pf.messages.LineRange, first, last)
+	return doc
+}
@@ -0,0 +1,183 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"aicodeprep-go/internal/tokenizer"
+)
+
+func TestRenderPartsSplitsLongLines(t *testing.T) {
+	// One line, as in a minified file, several times the part limit
+	line := strings.Repeat("var x=function(a,b){return a+b};", 200) + "\n"
+	docs := []document{
+		newDocument("small.go", "package small\n", 0),
+		newDocument("app.min.js", line, 0),
+	}
+
+	for _, format := range []string{FormatText, FormatMarkdown, FormatXML, FormatJSON} {
+		pf := New("Review this", nil, false)
+		pf.SetFormat(format)
+		pf.SetPartLimit(2000, false)
+
+		parts, err := pf.renderParts(docs)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(parts) < 3 {
+			t.Errorf("%s: %d parts, want the line split across several", format, len(parts))
+		}
+		for i, part := range parts {
+			if len(part) > 2000 {
+				t.Errorf("%s: part %d has %d bytes, want at most 2000", format, i+1, len(part))
+			}
+		}
+	}
+}
+
+func TestRenderPartsTokenLimit(t *testing.T) {
+	line := strings.Repeat("identifier ", 2000) + "\n"
+	pf := New("", nil, false)
+	pf.SetTokenizer(tokenizer.NewHeuristic())
+	pf.SetPartLimit(500, true)
+
+	parts, err := pf.renderParts([]document{newDocument("words.txt", line, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, part := range parts {
+		if tokens := pf.tokenizer.Count(part); tokens > 500 {
+			t.Errorf("part %d has %d tokens, want at most 500", i+1, tokens)
+		}
+	}
+}
+
+func TestRenderPartsLineSpans(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < 100; i++ {
+		content.WriteString("fmt.Println(\"some line of output\")\n")
+	}
+	pf := New("", nil, false)
+	pf.SetPartLimit(1500, false)
+
+	parts, err := pf.renderParts([]document{newDocument("long.go", content.String(), 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(parts[0], "long.go (lines 1-") {
+		t.Errorf("first part doesn't start at line 1:\n%s", parts[0])
+	}
+	if !strings.Contains(parts[len(parts)-1], "-100)") {
+		t.Errorf("last part doesn't end at line 100:\n%s", parts[len(parts)-1])
+	}
+}
+
+func TestRenderPartsDeclarationSpans(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < 100; i++ {
+		content.WriteString("fmt.Println(\"some line of output\")\n")
+	}
+	doc := newDocument("p.go", content.String(), 0)
+	doc.line = 120
+	pf := New("", nil, false)
+	pf.SetPartLimit(1500, false)
+
+	parts, err := pf.renderParts([]document{doc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(parts[0], "p.go:120 (lines 120-") {
+		t.Errorf("first part doesn't start at line 120:\n%s", parts[0])
+	}
+	if !strings.Contains(parts[len(parts)-1], "-219)") {
+		t.Errorf("last part doesn't end at line 219:\n%s", parts[len(parts)-1])
+	}
+}
+
+func TestRenderPartsLimitTooSmall(t *testing.T) {
+	pf := New("", nil, false)
+	pf.SetPartLimit(10, false)
+	if _, err := pf.renderParts([]document{newDocument("a.go", "package a\n", 0)}); err == nil {
+		t.Error("renderParts succeeded with a limit smaller than the prompt")
+	}
+}
+
+func TestRenderPartsFitsInOne(t *testing.T) {
+	docs := []document{newDocument("a.go", "package a\n", 0)}
+	pf := New("Review this", nil, false)
+	pf.SetPartLimit(1000, false)
+
+	parts, err := pf.renderParts(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := pf.render(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 1 || parts[0] != want {
+		t.Errorf("renderParts = %q, want the unsplit output %q", parts, want)
+	}
+}
+
+func TestRenderPartsNumbering(t *testing.T) {
+	var docs []document
+	for i := 0; i < 30; i++ {
+		docs = append(docs, newDocument(fmt.Sprintf("f%02d.go", i), strings.Repeat("x", 200)+"\n", 0))
+	}
+
+	// Parts of one and of two digits leave the room their notes don't take
+	for _, limit := range []int{3000, 600} {
+		pf := New("Review this", nil, false)
+		pf.SetPartLimit(limit, false)
+		parts, err := pf.renderParts(docs)
+		if err != nil {
+			t.Fatalf("limit %d: %v", limit, err)
+		}
+
+		note := fmt.Sprintf(" of %d", len(parts))
+		files := 0
+		for i, part := range parts {
+			if len(part) > limit {
+				t.Errorf("limit %d: part %d has %d bytes", limit, i+1, len(part))
+			}
+			if !strings.Contains(part, note) {
+				t.Errorf("limit %d: part %d isn't numbered%s", limit, i+1, note)
+			}
+			files += strings.Count(part, "--- File: ")
+		}
+		if files != len(docs) {
+			t.Errorf("limit %d: %d files in the parts, want %d", limit, files, len(docs))
+		}
+	}
+}
+
+func TestRenderPartsExactLimit(t *testing.T) {
+	docs := []document{
+		newDocument("a.go", strings.Repeat("a", 300)+"\n", 0),
+		newDocument("b.go", strings.Repeat("b", 300)+"\n", 0),
+	}
+
+	// The limit only leaves room for the notes of two parts
+	pf := New("Review this", nil, false)
+	limit := 0
+	for i, doc := range docs {
+		pf.prompt = pf.partPrompt("Review this", i+1, len(docs))
+		part, err := pf.render([]document{doc})
+		if err != nil {
+			t.Fatal(err)
+		}
+		limit = max(limit, len(part))
+	}
+	pf.prompt = "Review this"
+	pf.SetPartLimit(limit, false)
+
+	parts, err := pf.renderParts(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 2 || !strings.Contains(parts[0], "a.go ---") || !strings.Contains(parts[1], "b.go ---") {
+		t.Errorf("renderParts with limit %d = %q, want a part per file", limit, parts)
+	}
+}
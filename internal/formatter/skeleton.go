@@ -0,0 +1,70 @@
+package formatter
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+)
+
+// canOutline reports whether an outline can be made of the file at path
+func canOutline(path string) bool {
+	return filepath.Ext(path) == ".go"
+}
+
+// goSkeleton returns the declarations of Go source: the package clause,
+// imports, types, constants, variables and function signatures with their
+// doc comments. Function bodies and the comments inside them are left out.
+func goSkeleton(content string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep comments attached to declarations, plus build constraints and
+	// other comments above the package clause
+	keep := make(map[*ast.CommentGroup]bool)
+	for _, group := range file.Comments {
+		if group.End() < file.Package {
+			keep[group] = true
+		}
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncDecl:
+			keep[n.Doc] = true
+			n.Body = nil
+		case *ast.GenDecl:
+			keep[n.Doc] = true
+		case *ast.TypeSpec:
+			keep[n.Doc], keep[n.Comment] = true, true
+		case *ast.ValueSpec:
+			keep[n.Doc], keep[n.Comment] = true, true
+		case *ast.ImportSpec:
+			keep[n.Doc], keep[n.Comment] = true, true
+		case *ast.Field:
+			keep[n.Doc], keep[n.Comment] = true, true
+		case *ast.FuncLit:
+			return false // Function values keep their bodies but not their comments
+		}
+		return true
+	})
+
+	var comments []*ast.CommentGroup
+	for _, group := range file.Comments {
+		if keep[group] {
+			comments = append(comments, group)
+		}
+	}
+	file.Comments = comments
+
+	var result bytes.Buffer
+	config := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := config.Fprint(&result, fset, file); err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
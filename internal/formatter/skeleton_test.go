@@ -0,0 +1,104 @@
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"aicodeprep-go/internal/selector"
+)
+
+func TestGoSkeleton(t *testing.T) {
+	const source = `//go:build linux
+
+// Package store keeps items.
+package store
+
+import "strings" // For TrimSpace
+
+// Limit caps the items
+const Limit = 10
+
+// Store holds items
+type Store struct {
+	// items in insertion order
+	items []string // Never nil
+}
+
+// Add adds an item.
+// It trims the name first.
+func (s *Store) Add(name string) {
+	// Leading and trailing spaces don't count
+	name = strings.TrimSpace(name)
+	s.items = append(s.items, name) // Keep order
+}
+
+var hook = func() {
+	// Function values keep their bodies, without comments
+}
+`
+	const want = `//go:build linux
+
+// Package store keeps items.
+package store
+
+import "strings" // For TrimSpace
+
+// Limit caps the items
+const Limit = 10
+
+// Store holds items
+type Store struct {
+	// items in insertion order
+	items []string // Never nil
+}
+
+// Add adds an item.
+// It trims the name first.
+func (s *Store) Add(name string)
+
+var hook = func() {
+
+}
+`
+
+	got, err := goSkeleton(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("goSkeleton =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := goSkeleton("package broken\n\nfunc {\n"); err == nil {
+		t.Error("goSkeleton succeeded on a file that doesn't parse")
+	}
+}
+
+func TestLoadContentSkeletonFallback(t *testing.T) {
+	dir := t.TempDir()
+	const broken = "package broken\n\nfunc missingBody( {\n"
+	const valid = "package ok\n\nfunc f() {\n\treturn\n}\n"
+	for name, content := range map[string]string{"broken.go": broken, "ok.go": valid} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pf := New("", nil, false)
+
+	content, outline, err := pf.loadContent(selector.FileInfo{Path: filepath.Join(dir, "broken.go"), Skeleton: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outline || content != broken {
+		t.Errorf("loadContent of a file that doesn't parse = %q (outline %v), want the full content", content, outline)
+	}
+
+	content, outline, err = pf.loadContent(selector.FileInfo{Path: filepath.Join(dir, "ok.go"), Skeleton: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !outline || content != "package ok\n\nfunc f()\n" {
+		t.Errorf("loadContent = %q (outline %v), want the outline", content, outline)
+	}
+}
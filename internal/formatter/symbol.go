@@ -0,0 +1,354 @@
+package formatter
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"aicodeprep-go/internal/gomod"
+	"aicodeprep-go/internal/selector"
+)
+
+// goPackage holds the top-level declarations of one package in the selection
+type goPackage struct {
+	name    string
+	dir     string
+	files   []*goFile
+	decls   map[string]*goDecl // By name, methods as "Type.Method"
+	checked *types.Package     // Set once type-checked
+}
+
+// goFile is a parsed Go file of the selection
+type goFile struct {
+	info    selector.FileInfo
+	content string
+	fset    *token.FileSet
+	syntax  *ast.File
+}
+
+// goDecl is a top-level declaration. Grouped const, var and type
+// declarations are kept whole, so every name of the group maps to the same
+// goDecl.
+type goDecl struct {
+	file *goFile
+	node ast.Decl
+}
+
+// goIndex resolves identifiers of the selection to its declarations
+type goIndex struct {
+	uses  map[*ast.Ident]types.Object // From type-checking the packages
+	decls map[token.Pos]*goDecl       // By the position of their names
+}
+
+// SetSymbols replaces the file contents by the Go declarations named by
+// symbols, given as "pkg.Name" or "pkg.Type.Method", together with the
+// declarations they reference up to depth levels away
+func (pf *PromptFormatter) SetSymbols(symbols []string, depth int) error {
+	for _, symbol := range symbols {
+		if strings.Count(symbol, ".") < 1 || strings.Count(symbol, ".") > 2 {
+			return fmt.Errorf("invalid symbol '%s', expected pkg.Name or pkg.Type.Method", symbol)
+		}
+	}
+	if depth < 0 {
+		return fmt.Errorf("symbol depth must not be negative")
+	}
+	pf.symbols = symbols
+	pf.symbolDepth = depth
+	return nil
+}
+
+// symbolDocuments returns one document per declaration reachable from the
+// requested symbols, the requested ones first
+func (pf *PromptFormatter) symbolDocuments() ([]document, error) {
+	packages, index := pf.parsePackages()
+
+	var queue []*goDecl
+	seen := make(map[*goDecl]bool)
+	for _, symbol := range pf.symbols {
+		parts := strings.SplitN(symbol, ".", 2)
+		found := false
+		for _, pkg := range packages {
+			if decl := pkg.decls[parts[1]]; pkg.name == parts[0] && decl != nil {
+				found = true
+				if !seen[decl] {
+					seen[decl] = true
+					queue = append(queue, decl)
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("symbol '%s' not found in the selected files", symbol)
+		}
+	}
+
+	// Breadth-first, so closer dependencies come first
+	level := queue
+	for depth := 0; depth < pf.symbolDepth && len(level) > 0; depth++ {
+		var next []*goDecl
+		for _, decl := range level {
+			for _, ref := range index.references(decl) {
+				if !seen[ref] {
+					seen[ref] = true
+					next = append(next, ref)
+				}
+			}
+		}
+		queue = append(queue, next...)
+		level = next
+	}
+
+	docs := make([]document, 0, len(queue))
+	for _, decl := range queue {
+		docs = append(docs, pf.declDocument(decl))
+	}
+	return docs, nil
+}
+
+// parsePackages parses the Go files of the selection, indexes their
+// top-level declarations by package and type-checks the packages so
+// identifiers can be resolved to declarations
+func (pf *PromptFormatter) parsePackages() ([]*goPackage, *goIndex) {
+	type packageKey struct{ dir, name string }
+	byKey := make(map[packageKey]*goPackage)
+	byDir := make(map[string]*goPackage) // Non-test packages, for imports
+	var packages []*goPackage
+	fset := token.NewFileSet()
+	index := &goIndex{
+		uses:  make(map[*ast.Ident]types.Object),
+		decls: make(map[token.Pos]*goDecl),
+	}
+
+	for _, info := range pf.files {
+		if info.Binary || filepath.Ext(info.Path) != ".go" {
+			continue
+		}
+		content, err := pf.readFileContent(info)
+		if err != nil {
+			if pf.verbose {
+				fmt.Fprintf(os.Stderr, pf.messages.FailedToReadFile+"\n", info.Path, err)
+			}
+			continue
+		}
+		syntax, err := parser.ParseFile(fset, info.Path, content, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			if pf.verbose {
+				fmt.Fprintf(os.Stderr, pf.messages.FailedToParse+"\n", info.Path, err)
+			}
+			continue
+		}
+
+		dir := filepath.Dir(info.Path)
+		key := packageKey{dir, syntax.Name.Name}
+		pkg := byKey[key]
+		if pkg == nil {
+			pkg = &goPackage{name: syntax.Name.Name, dir: dir, decls: make(map[string]*goDecl)}
+			byKey[key] = pkg
+			packages = append(packages, pkg)
+			if !strings.HasSuffix(pkg.name, "_test") {
+				byDir[dir] = pkg
+			}
+		}
+
+		file := &goFile{info: info, content: content, fset: fset, syntax: syntax}
+		pkg.files = append(pkg.files, file)
+		for _, node := range syntax.Decls {
+			decl := &goDecl{file: file, node: node}
+			names, idents := declNames(node)
+			for i, name := range names {
+				if name != "_" && name != "init" {
+					pkg.decls[name] = decl
+					index.decls[idents[i].Pos()] = decl
+				}
+			}
+		}
+	}
+
+	importer := &packageImporter{
+		pf:      pf,
+		fset:    fset,
+		index:   index,
+		byDir:   byDir,
+		modules: gomod.NewFinder(os.ReadFile),
+		fake:    make(map[string]*types.Package),
+		missing: make(map[string]bool),
+	}
+	for _, pkg := range packages {
+		importer.check(pkg)
+	}
+	return packages, index
+}
+
+// declNames returns the names a top-level declaration is indexed by,
+// methods as "Type.Method", and the identifiers declaring them
+func declNames(node ast.Decl) ([]string, []*ast.Ident) {
+	var names []string
+	var idents []*ast.Ident
+	switch decl := node.(type) {
+	case *ast.FuncDecl:
+		if decl.Recv == nil {
+			return []string{decl.Name.Name}, []*ast.Ident{decl.Name}
+		}
+		if len(decl.Recv.List) > 0 {
+			if recv := baseTypeName(decl.Recv.List[0].Type); recv != "" {
+				return []string{recv + "." + decl.Name.Name}, []*ast.Ident{decl.Name}
+			}
+		}
+	case *ast.GenDecl:
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, spec.Name.Name)
+				idents = append(idents, spec.Name)
+			case *ast.ValueSpec:
+				for _, name := range spec.Names {
+					names = append(names, name.Name)
+					idents = append(idents, name)
+				}
+			}
+		}
+	}
+	return names, idents
+}
+
+// baseTypeName returns the name of a receiver type, without pointer and
+// type parameters
+func baseTypeName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// packageImporter type-checks the packages of the selection, resolving
+// imports of the module to the selected packages. Other imports, such as
+// the standard library, are stood in for by empty packages, so their uses
+// stay unresolved.
+type packageImporter struct {
+	pf      *PromptFormatter
+	fset    *token.FileSet
+	index   *goIndex
+	byDir   map[string]*goPackage
+	modules *gomod.Finder
+	fake    map[string]*types.Package // Stand-ins by import path
+	missing map[string]bool           // Module packages reported as not selected
+}
+
+// check type-checks pkg once, recording the uses of its identifiers.
+// Type errors are expected with stand-in imports and are ignored.
+func (im *packageImporter) check(pkg *goPackage) *types.Package {
+	if pkg.checked != nil {
+		return pkg.checked
+	}
+	// Marks pkg as in progress, an import cycle gets the empty package
+	pkg.checked = types.NewPackage(pkg.dir, pkg.name)
+
+	syntax := make([]*ast.File, len(pkg.files))
+	for i, file := range pkg.files {
+		syntax[i] = file.syntax
+	}
+	config := types.Config{Importer: im, Error: func(error) {}}
+	checked, _ := config.Check(pkg.dir, im.fset, syntax, &types.Info{Uses: im.index.uses})
+	pkg.checked = checked
+	return checked
+}
+
+// Import implements types.Importer for imports that don't need a directory
+func (im *packageImporter) Import(importPath string) (*types.Package, error) {
+	return im.ImportFrom(importPath, "", 0)
+}
+
+// ImportFrom implements types.ImporterFrom, resolving importPath within the
+// module of dir
+func (im *packageImporter) ImportFrom(importPath, dir string, _ types.ImportMode) (*types.Package, error) {
+	if dir != "" {
+		if pkgDir, ok := im.modules.Find(dir).Dir(importPath); ok {
+			if pkg := im.byDir[pkgDir]; pkg != nil {
+				return im.check(pkg), nil
+			}
+			if !im.missing[importPath] {
+				im.missing[importPath] = true
+				fmt.Fprintf(os.Stderr, im.pf.messages.NotSelected+"\n", importPath)
+			}
+		}
+	}
+
+	if pkg := im.fake[importPath]; pkg != nil {
+		return pkg, nil
+	}
+	pkg := types.NewPackage(importPath, path.Base(importPath))
+	pkg.MarkComplete()
+	im.fake[importPath] = pkg
+	return pkg, nil
+}
+
+// references returns the declarations of the selection that decl refers
+// to, as resolved by the type checker. Struct fields, such as the keys of
+// composite literals, and local names resolve to objects that aren't
+// top-level declarations and are left out.
+func (index *goIndex) references(decl *goDecl) []*goDecl {
+	var refs []*goDecl
+	seen := map[*goDecl]bool{decl: true}
+	ast.Inspect(decl.node, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		if obj := index.uses[ident]; obj != nil {
+			if ref := index.decls[obj.Pos()]; ref != nil && !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+		return true
+	})
+	return refs
+}
+
+// declDocument returns the source of decl, with its doc comment, as a
+// document headed by the line it starts on
+func (pf *PromptFormatter) declDocument(decl *goDecl) document {
+	start := decl.node.Pos()
+	switch node := decl.node.(type) {
+	case *ast.FuncDecl:
+		if node.Doc != nil {
+			start = node.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if node.Doc != nil {
+			start = node.Doc.Pos()
+		}
+	}
+
+	tokenFile := decl.file.fset.File(start)
+	from, to := tokenFile.Offset(start), tokenFile.Offset(decl.node.End())
+	line := tokenFile.Line(start)
+
+	firstLine := 0
+	if pf.lineNumbers {
+		firstLine = line
+	}
+	return document{
+		file:    decl.file.info,
+		path:    GetRelativePath(decl.file.info.Path),
+		content: pf.processLines(decl.file.content[from:to]+"\n", firstLine),
+		line:    line,
+	}
+}
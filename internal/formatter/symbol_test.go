@@ -0,0 +1,158 @@
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"aicodeprep-go/internal/selector"
+)
+
+const storeSource = `
+package store
+
+import "strings"
+
+// Field shares its name with a struct field
+var Field = "unused"
+
+// Limit caps the number of items
+const Limit = 10
+
+// Item is a stored item
+type Item struct {
+	Field string
+	Name  string
+}
+
+// Store holds items
+type Store struct {
+	items []Item
+}
+
+// Add adds an item
+func (s *Store) Add(name string) {
+	item := Item{Field: name, Name: strings.TrimSpace(name)}
+	s.items = append(s.items, item)
+	s.trim()
+}
+
+func (s *Store) trim() {
+	if len(s.items) > Limit {
+		s.items = s.items[:Limit]
+	}
+}
+
+// Count shadows Limit with a local
+func Count(s *Store) int {
+	Limit := len(s.items)
+	return Limit
+}
+`
+
+const mainSource = `package main
+
+import "example.com/m/store"
+
+func run() {
+	s := &store.Store{}
+	s.Add("x")
+}
+`
+
+// newSymbolFormatter writes a small module and returns a formatter for its
+// files, run from the module root
+func newSymbolFormatter(t *testing.T) *PromptFormatter {
+	t.Helper()
+	root := t.TempDir()
+	var files []selector.FileInfo
+	for rel, content := range map[string]string{
+		"go.mod":         "module example.com/m\n",
+		"store/store.go": strings.TrimPrefix(storeSource, "\n"),
+		"main.go":        mainSource,
+	} {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Ext(rel) == ".go" {
+			files = append(files, selector.FileInfo{Path: path, Size: int64(len(content))})
+		}
+	}
+	t.Chdir(root)
+	return New("", files, false)
+}
+
+func TestSymbolDocuments(t *testing.T) {
+	tests := []struct {
+		name   string
+		symbol string
+		depth  int
+		want   []string
+	}{
+		{"method lookup", "store.Store.Add", 0, []string{"store/store.go:22"}},
+		// Receiver, types and methods; the composite literal key Field isn't the variable
+		{"direct references", "store.Store.Add", 1,
+			[]string{"store/store.go:22", "store/store.go:17", "store/store.go:11", "store/store.go:29"}},
+		{"second level", "store.Store.Add", 2,
+			[]string{"store/store.go:22", "store/store.go:17", "store/store.go:11", "store/store.go:29", "store/store.go:8"}},
+		{"local shadowing", "store.Count", 1, []string{"store/store.go:35", "store/store.go:17"}},
+		{"across packages", "main.run", 1, []string{"main.go:5", "store/store.go:17", "store/store.go:22"}},
+	}
+
+	for _, tt := range tests {
+		pf := newSymbolFormatter(t)
+		if err := pf.SetSymbols([]string{tt.symbol}, tt.depth); err != nil {
+			t.Fatal(err)
+		}
+		docs, err := pf.symbolDocuments()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, doc := range docs {
+			got = append(got, filepath.ToSlash(doc.path)+":"+strconv.Itoa(doc.line))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSymbolHeaders(t *testing.T) {
+	pf := newSymbolFormatter(t)
+	if err := pf.SetSymbols([]string{"store.Store.trim"}, 1); err != nil {
+		t.Fatal(err)
+	}
+	pf.SetLineNumbers(true)
+
+	result, err := pf.Format()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"--- File: store/store.go:29 ---\n29 | func (s *Store) trim() {\n",
+		"--- File: store/store.go:17 ---\n17 | // Store holds items\n",
+		"--- File: store/store.go:8 ---\n8 | // Limit caps the number of items\n9 | const Limit = 10\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestSymbolNotFound(t *testing.T) {
+	pf := newSymbolFormatter(t)
+	if err := pf.SetSymbols([]string{"store.Missing"}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pf.symbolDocuments(); err == nil {
+		t.Error("symbolDocuments succeeded for a missing symbol")
+	}
+}
@@ -0,0 +1,128 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateData is the data passed to user-defined output templates
+type templateData struct {
+	Prompt     string         // User prompt as given, may be empty
+	Diff       string         // Unified diff when --diff is used
+	Files      []templateFile // Files in selection order
+	TotalFiles int
+	TotalSize  int64  // Size of the contents as output
+	Tree       string // Directory tree of the files
+}
+
+// templateFile describes one file for templates
+type templateFile struct {
+	Path     string // Display path, relative to the working directory if possible
+	Revision string // Git revision the file was read from, empty for the working tree
+	Range    string // Line range when the file is split across parts
+	Line     int    // First line of an extracted declaration, 0 for whole files
+	Content  string
+	Size     int64 // Size of Content, which may differ from the file on disk
+	Lines    int
+	Language string
+	Binary   bool   // Content is a placeholder
+	MIME     string // Content type of a binary file
+	Skeleton bool   // Content is the declarations only
+}
+
+// templateFuncs are the helper functions available to templates
+var templateFuncs = template.FuncMap{
+	"indent":      indentLines,
+	"fence":       fenceBlock,
+	"escapeXML":   escapeXML,
+	"cdata":       wrapCDATA,
+	"lineNumbers": numberLines,
+	"formatBytes": formatBytes,
+	"trimSpace":   strings.TrimSpace,
+}
+
+// SetTemplate renders output through the Go text/template at path instead
+// of a built-in format
+func (pf *PromptFormatter) SetTemplate(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	pf.template = tmpl
+	return nil
+}
+
+// renderTemplate executes the user-defined template
+func (pf *PromptFormatter) renderTemplate(docs []document) (string, error) {
+	data := templateData{
+		Prompt: pf.prompt,
+		Diff:   pf.diff,
+	}
+
+	var paths []string
+	for _, doc := range docs {
+		data.Files = append(data.Files, templateFile{
+			Path:     doc.path,
+			Revision: doc.file.Rev,
+			Range:    doc.span,
+			Line:     doc.line,
+			Content:  doc.content,
+			Size:     int64(len(doc.content)),
+			Lines:    countLines(doc.content),
+			Language: detectLanguage(doc.path),
+			Binary:   doc.file.Binary,
+			MIME:     doc.file.MIME,
+			Skeleton: doc.outline,
+		})
+		data.TotalSize += int64(len(doc.content))
+		paths = append(paths, filepath.ToSlash(doc.path))
+	}
+	data.TotalFiles = len(data.Files)
+
+	// The tree of --tree, relative to the project root; without it, one of
+	// the display paths
+	data.Tree = pf.tree
+	if data.Tree == "" {
+		data.Tree = buildTree(paths)
+	}
+
+	var result strings.Builder
+	if err := pf.template.Execute(&result, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return result.String(), nil
+}
+
+// indentLines prefixes every non-empty line of s with n spaces
+func indentLines(n int, s string) string {
+	padding := strings.Repeat(" ", n)
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if line != "" && line != "\n" {
+			lines[i] = padding + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// fenceBlock wraps content in a fenced code block with the given info string
+func fenceBlock(info, content string) string {
+	var result strings.Builder
+	writeFenced(&result, content, info)
+	return result.String()
+}
+
+// numberLines prefixes each line of content with its number in a gutter as
+// wide as the largest number
+func numberLines(content string) string {
+	return rewriteLines(content, 1, 0, "")
+}
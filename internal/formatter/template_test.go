@@ -0,0 +1,78 @@
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"aicodeprep-go/internal/selector"
+)
+
+// testTemplate uses every field and helper function available to templates
+const testTemplate = `{{.TotalFiles}} files, {{formatBytes .TotalSize}}
+{{.Tree}}{{range .Files}}== {{.Path}} ({{.Size}} bytes, {{.Lines}} lines, {{.Language}}){{if .Skeleton}} skeleton{{end}}
+{{fence .Language .Content}}{{lineNumbers .Content}}{{indent 2 .Content}}<file path="{{escapeXML .Path}}">{{cdata .Content}}</file>
+{{end}}{{trimSpace .Prompt}}
+`
+
+const wantTemplateOutput = "2 files, 30 B\n" +
+	".\n" +
+	"└── src/\n" +
+	"    ├── a&b.go (999 B)\n" +
+	"    └── page.xml (5 B)\n" +
+	"== src/a&b.go (19 bytes, 2 lines, go) skeleton\n" +
+	"```go\npackage a\nfunc f()\n```\n" +
+	"1 | package a\n2 | func f()\n" +
+	"  package a\n  func f()\n" +
+	"<file path=\"src/a&amp;b.go\"><![CDATA[package a\nfunc f()\n]]></file>\n" +
+	"== src/page.xml (11 bytes, 1 lines, xml)\n" +
+	"```xml\n<a>]]></a>\n```\n" +
+	"1 | <a>]]></a>\n" +
+	"  <a>]]></a>\n" +
+	"<file path=\"src/page.xml\"><![CDATA[<a>]]]]><![CDATA[></a>\n]]></file>\n" +
+	"Review this\n"
+
+func TestRenderTemplate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prompt.tmpl")
+	if err := os.WriteFile(path, []byte(testTemplate), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sizes on disk differ from the output, as for a skeleton or a transcoded file
+	root := filepath.Join(dir, "project")
+	skeleton := newDocument("src/a&b.go", "package a\nfunc f()\n", 0)
+	skeleton.file = selector.FileInfo{Path: filepath.Join(root, "src", "a&b.go"), Size: 999, Skeleton: true}
+	skeleton.outline = true
+	page := newDocument("src/page.xml", "<a>]]></a>\n", 0)
+	page.file = selector.FileInfo{Path: filepath.Join(root, "src", "page.xml"), Size: 5}
+
+	pf := New("  Review this  ", []selector.FileInfo{skeleton.file, page.file}, false)
+	if err := pf.SetTemplate(path); err != nil {
+		t.Fatal(err)
+	}
+	pf.SetTree(nil, root)
+
+	got, err := pf.renderTemplate([]document{skeleton, page})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != wantTemplateOutput {
+		t.Errorf("renderTemplate =\n%s\nwant\n%s", got, wantTemplateOutput)
+	}
+}
+
+func TestSetTemplateErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := New("", nil, false).SetTemplate(filepath.Join(dir, "missing.tmpl")); err == nil {
+		t.Error("SetTemplate succeeded with a missing file")
+	}
+
+	path := filepath.Join(dir, "broken.tmpl")
+	if err := os.WriteFile(path, []byte("{{range .Files}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := New("", nil, false).SetTemplate(path); err == nil {
+		t.Error("SetTemplate succeeded with a template that doesn't parse")
+	}
+}
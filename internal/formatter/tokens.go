@@ -0,0 +1,76 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"aicodeprep-go/internal/selector"
+	"aicodeprep-go/internal/tokenizer"
+)
+
+// Orders of the per-file report in the summary and verbose output
+const (
+	SortNone   = ""       // Selection order
+	SortPath   = "path"   // Alphabetical
+	SortSize   = "size"   // Largest first
+	SortTokens = "tokens" // Most tokens first
+)
+
+// fileTokens is one line of the per-file report
+type fileTokens struct {
+	file   selector.FileInfo
+	path   string // Display path
+	tokens int
+}
+
+// SetTokenizer enables token counts in the summary and verbose output
+func (pf *PromptFormatter) SetTokenizer(t tokenizer.Tokenizer) {
+	pf.tokenizer = t
+}
+
+// SetSortBy orders the per-file report, one of the Sort* constants
+func (pf *PromptFormatter) SetSortBy(sortBy string) error {
+	switch sortBy {
+	case SortNone, SortPath, SortSize, SortTokens:
+		pf.sortBy = sortBy
+		return nil
+	default:
+		return fmt.Errorf("unknown sort order '%s' (expected path, size or tokens)", sortBy)
+	}
+}
+
+// sortReport orders entries by pf.sortBy; ties keep their selection order
+func (pf *PromptFormatter) sortReport(entries []fileTokens) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		switch pf.sortBy {
+		case SortPath:
+			return entries[i].path < entries[j].path
+		case SortSize:
+			return entries[i].file.Size > entries[j].file.Size
+		case SortTokens:
+			return entries[i].tokens > entries[j].tokens
+		default:
+			return false
+		}
+	})
+}
+
+// writeTokenReport writes the tokens of each document and the total,
+// along with the tokens of the rendered output
+func (pf *PromptFormatter) writeTokenReport(w io.Writer, docs []document, output string) {
+	entries := make([]fileTokens, 0, len(docs))
+	total := 0
+	for _, doc := range docs {
+		tokens := pf.tokenizer.Count(doc.content)
+		entries = append(entries, fileTokens{file: doc.file, path: doc.header(), tokens: tokens})
+		total += tokens
+	}
+	pf.sortReport(entries)
+
+	fmt.Fprintf(w, pf.messages.TokenReport+"\n", pf.tokenizer.Name())
+	for _, entry := range entries {
+		fmt.Fprintf(w, "  %8d  %s\n", entry.tokens, entry.path)
+	}
+	fmt.Fprintf(w, pf.messages.TokenTotal+"\n", total, pf.tokenizer.Count(output))
+}
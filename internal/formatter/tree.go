@@ -0,0 +1,132 @@
+package formatter
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"aicodeprep-go/internal/selector"
+)
+
+// treeNode is a directory or file in the rendered directory tree
+type treeNode struct {
+	name       string
+	children   map[string]*treeNode
+	isFile     bool
+	size       int64 // File size, negative when not shown
+	unselected bool  // File is in the project but not in the prompt
+}
+
+// treeItem is a file to place in the directory tree
+type treeItem struct {
+	path       string // Slash-separated
+	size       int64  // Negative to leave the size out
+	unselected bool
+}
+
+// SetTree adds a directory tree of the selected files before the file
+// contents, with paths relative to root. With all set, the tree shows every
+// file in all as well and marks the files that aren't selected.
+func (pf *PromptFormatter) SetTree(all []selector.FileInfo, root string) {
+	selected := make(map[string]bool, len(pf.files))
+	for _, file := range pf.files {
+		selected[file.Path] = true
+	}
+
+	var items []treeItem
+	listed := make(map[string]bool, len(all)+len(pf.files))
+	for _, file := range append(append([]selector.FileInfo(nil), all...), pf.files...) {
+		if listed[file.Path] {
+			continue
+		}
+		listed[file.Path] = true
+
+		relPath, err := filepath.Rel(root, file.Path)
+		if err != nil {
+			relPath = file.Path
+		}
+		items = append(items, treeItem{
+			path:       filepath.ToSlash(relPath),
+			size:       file.Size,
+			unselected: !selected[file.Path],
+		})
+	}
+
+	pf.tree = renderTree(items, pf.messages.TreeUnselected)
+}
+
+// buildTree renders slash-separated paths as an indented directory tree
+func buildTree(paths []string) string {
+	items := make([]treeItem, len(paths))
+	for i, path := range paths {
+		items[i] = treeItem{path: path, size: -1}
+	}
+	return renderTree(items, "")
+}
+
+// renderTree renders items as an indented directory tree, noting file sizes
+// and marking unselected files with mark
+func renderTree(items []treeItem, mark string) string {
+	root := &treeNode{children: make(map[string]*treeNode)}
+	for _, item := range items {
+		node := root
+		segments := strings.Split(strings.Trim(item.path, "/"), "/")
+		for i, segment := range segments {
+			child, ok := node.children[segment]
+			if !ok {
+				child = &treeNode{name: segment, children: make(map[string]*treeNode), size: -1}
+				node.children[segment] = child
+			}
+			if i == len(segments)-1 {
+				child.isFile = true
+				child.size = item.size
+				child.unselected = item.unselected
+			}
+			node = child
+		}
+	}
+
+	var result strings.Builder
+	result.WriteString(".\n")
+	writeTree(&result, root, "", mark)
+	return result.String()
+}
+
+// writeTree writes the children of node, directories first, each group sorted by name
+func writeTree(result *strings.Builder, node *treeNode, indent, mark string) {
+	children := make([]*treeNode, 0, len(node.children))
+	for _, child := range node.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		iDir, jDir := len(children[i].children) > 0, len(children[j].children) > 0
+		if iDir != jDir {
+			return iDir
+		}
+		return children[i].name < children[j].name
+	})
+
+	for i, child := range children {
+		branch, nextIndent := "├── ", indent+"│   "
+		if i == len(children)-1 {
+			branch, nextIndent = "└── ", indent+"    "
+		}
+
+		result.WriteString(indent)
+		result.WriteString(branch)
+		result.WriteString(child.name)
+		if len(child.children) > 0 {
+			result.WriteString("/")
+		}
+		if child.isFile && child.size >= 0 {
+			result.WriteString(fmt.Sprintf(" (%s)", formatBytes(child.size)))
+		}
+		if child.unselected {
+			result.WriteString(fmt.Sprintf(" [%s]", mark))
+		}
+		result.WriteString("\n")
+
+		writeTree(result, child, nextIndent, mark)
+	}
+}
@@ -0,0 +1,32 @@
+package formatter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"aicodeprep-go/internal/selector"
+)
+
+func TestSetTreeRelativeToRoot(t *testing.T) {
+	root := filepath.FromSlash("/project")
+	main := selector.FileInfo{Path: filepath.Join(root, "cmd", "main.go"), Size: 10}
+	readme := selector.FileInfo{Path: filepath.Join(root, "README.md"), Size: 20}
+
+	want := ".\n" +
+		"└── cmd/\n" +
+		"    └── main.go (10 B)\n"
+	pf := New("", []selector.FileInfo{main}, false)
+	pf.SetTree(nil, root)
+	if pf.tree != want {
+		t.Errorf("selected tree =\n%s\nwant\n%s", pf.tree, want)
+	}
+
+	want = ".\n" +
+		"├── cmd/\n" +
+		"│   └── main.go (10 B)\n" +
+		"└── README.md (20 B) [not selected]\n"
+	pf.SetTree([]selector.FileInfo{readme, main}, root)
+	if pf.tree != want {
+		t.Errorf("full tree =\n%s\nwant\n%s", pf.tree, want)
+	}
+}
@@ -0,0 +1,114 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderXML renders the files as <documents>, the layout recommended for
+// long-context prompts, with the user prompt in <instructions>
+func (pf *PromptFormatter) renderXML(docs []document) string {
+	var result strings.Builder
+
+	// Add user prompt at the beginning
+	if pf.promptAtTop() {
+		result.WriteString("<instructions>\n")
+		if pf.prompt != "" {
+			result.WriteString(escapeXML(pf.prompt))
+		} else {
+			result.WriteString(escapeXML(pf.messages.DefaultPromptBefore))
+		}
+		result.WriteString("\n</instructions>\n\n")
+	}
+
+	// Add directory tree
+	if pf.tree != "" {
+		result.WriteString("<directory_tree>\n")
+		result.WriteString(pf.xmlContent(pf.tree))
+		result.WriteString("\n</directory_tree>\n\n")
+	}
+
+	// Add diff section
+	if pf.diff != "" {
+		result.WriteString("<diff>\n")
+		result.WriteString(pf.xmlContent(pf.diff))
+		result.WriteString("\n</diff>\n\n")
+	}
+
+	if !pf.diffOnly {
+		result.WriteString("<documents>\n")
+		for i, doc := range docs {
+			result.WriteString(fmt.Sprintf("<document index=\"%d\">\n", i+1))
+			result.WriteString(fmt.Sprintf("<source>%s</source>\n", escapeXML(doc.header())))
+			result.WriteString("<document_content>\n")
+			result.WriteString(pf.xmlContent(doc.content))
+			result.WriteString("\n</document_content>\n")
+			result.WriteString("</document>\n")
+		}
+		result.WriteString("</documents>\n\n")
+	}
+
+	// Add user prompt at the end again
+	if pf.promptAtBottom() {
+		result.WriteString("<instructions>\n")
+		if pf.prompt != "" {
+			result.WriteString(escapeXML(pf.prompt))
+		} else {
+			result.WriteString(escapeXML(pf.messages.DefaultPromptAfter))
+		}
+		result.WriteString("\n</instructions>\n")
+	}
+
+	return result.String()
+}
+
+// xmlContent makes content safe to embed in an element,
+// either as a CDATA section or as escaped character data
+func (pf *PromptFormatter) xmlContent(content string) string {
+	content = strings.TrimSuffix(content, "\n")
+	if pf.xmlCDATA {
+		return wrapCDATA(strings.Map(replaceInvalidXML, content))
+	}
+	return escapeXML(content)
+}
+
+// wrapCDATA wraps s in a CDATA section, splitting any "]]>" inside s
+// across two sections so it can't end the section early
+func wrapCDATA(s string) string {
+	return "<![CDATA[" + strings.ReplaceAll(s, "]]>", "]]]]><![CDATA[>") + "]]>"
+}
+
+// escapeXML escapes the characters that are special in XML character data
+func escapeXML(s string) string {
+	var result strings.Builder
+	for _, r := range s {
+		switch r {
+		case '&':
+			result.WriteString("&amp;")
+		case '<':
+			result.WriteString("&lt;")
+		case '>':
+			result.WriteString("&gt;")
+		case '"':
+			result.WriteString("&quot;")
+		case '\'':
+			result.WriteString("&apos;")
+		default:
+			result.WriteRune(replaceInvalidXML(r))
+		}
+	}
+	return result.String()
+}
+
+// replaceInvalidXML replaces characters that XML 1.0 doesn't allow, such as
+// most control characters, with U+FFFD
+func replaceInvalidXML(r rune) rune {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return r
+	case r >= 0x20 && r <= 0xD7FF, r >= 0xE000 && r <= 0xFFFD, r >= 0x10000 && r <= 0x10FFFF:
+		return r
+	default:
+		return '\uFFFD'
+	}
+}
@@ -0,0 +1,137 @@
+package formatter
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// xmlPrompt is the structure of the XML output, for parsing it back
+type xmlPrompt struct {
+	Instructions []string `xml:"instructions"`
+	Documents    []struct {
+		Index   int    `xml:"index,attr"`
+		Source  string `xml:"source"`
+		Content string `xml:"document_content"`
+	} `xml:"documents>document"`
+}
+
+// parseXMLPrompt parses the output of renderXML, which has several top-level
+// elements, inside a root element
+func parseXMLPrompt(t *testing.T, output string) xmlPrompt {
+	t.Helper()
+	var prompt xmlPrompt
+	if err := xml.Unmarshal([]byte("<prompt>"+output+"</prompt>"), &prompt); err != nil {
+		t.Fatalf("output doesn't parse: %v\n%s", err, output)
+	}
+	return prompt
+}
+
+func TestRenderXML(t *testing.T) {
+	docs := []document{
+		newDocument(`we"ird<&>.go`, "a ]]> b\x01c\x7fd\n", 0),
+		newDocument("ok.go", "package ok\n", 0),
+	}
+	const prompt = `Fix <this> & "that"`
+
+	for _, cdata := range []bool{true, false} {
+		pf := New(prompt, nil, false)
+		pf.SetXMLCDATA(cdata)
+		output := pf.renderXML(docs)
+		if cdata && !strings.Contains(output, "<![CDATA[a ]]]]><![CDATA[> b") {
+			t.Errorf("]]> isn't split across CDATA sections:\n%s", output)
+		}
+
+		parsed := parseXMLPrompt(t, output)
+		if len(parsed.Instructions) != 2 || parsed.Instructions[0] != "\n"+prompt+"\n" || parsed.Instructions[1] != parsed.Instructions[0] {
+			t.Errorf("cdata %v: instructions %q, want the prompt before and after the documents", cdata, parsed.Instructions)
+		}
+		if len(parsed.Documents) != 2 {
+			t.Fatalf("cdata %v: %d documents, want 2", cdata, len(parsed.Documents))
+		}
+		doc := parsed.Documents[0]
+		if doc.Index != 1 || doc.Source != `we"ird<&>.go` {
+			t.Errorf("cdata %v: document %d from %q, want 1 from the path as given", cdata, doc.Index, doc.Source)
+		}
+		// Control characters XML doesn't allow are replaced
+		if want := "\na ]]> b�c\x7fd\n"; doc.Content != want {
+			t.Errorf("cdata %v: content %q, want %q", cdata, doc.Content, want)
+		}
+	}
+}
+
+func TestRenderXMLPromptPosition(t *testing.T) {
+	tests := []struct {
+		position string
+		want     []string
+	}{
+		{PromptTop, []string{"\nReview\n"}},
+		{PromptBottom, []string{"\nReview\n"}},
+		{PromptBoth, []string{"\nReview\n", "\nReview\n"}},
+	}
+
+	for _, tt := range tests {
+		pf := New("Review", nil, false)
+		pf.SetPromptPosition(tt.position)
+		output := pf.renderXML([]document{newDocument("a.go", "package a\n", 0)})
+
+		parsed := parseXMLPrompt(t, output)
+		if len(parsed.Instructions) != len(tt.want) {
+			t.Errorf("%s: instructions %q, want %q", tt.position, parsed.Instructions, tt.want)
+		}
+		documents := strings.Index(output, "<documents>")
+		if tt.position == PromptTop && strings.LastIndex(output, "<instructions>") > documents {
+			t.Errorf("%s: prompt after the documents:\n%s", tt.position, output)
+		}
+		if tt.position == PromptBottom && strings.Index(output, "<instructions>") < documents {
+			t.Errorf("%s: prompt before the documents:\n%s", tt.position, output)
+		}
+	}
+}
+
+func TestEscapeXMLAttribute(t *testing.T) {
+	values := []string{`a "quoted" path`, "it's <b> & c", "tab\there", "bell\x07"}
+	for _, value := range values {
+		var parsed struct {
+			Path string `xml:"path,attr"`
+		}
+		data := `<file path="` + escapeXML(value) + `"/>`
+		if err := xml.Unmarshal([]byte(data), &parsed); err != nil {
+			t.Errorf("%q: %v", data, err)
+			continue
+		}
+		want := strings.Map(replaceInvalidXML, value)
+		if parsed.Path != want {
+			t.Errorf("attribute %q parsed as %q, want %q", data, parsed.Path, want)
+		}
+	}
+	// Single-quoted attributes are safe as well
+	if escaped := escapeXML(`'`); escaped != "&apos;" {
+		t.Errorf("escapeXML(') = %q, want &apos;", escaped)
+	}
+}
+
+func TestReplaceInvalidXML(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want rune
+	}{
+		{'\t', '\t'},
+		{'\n', '\n'},
+		{'\r', '\r'},
+		{'a', 'a'},
+		{0x00, '�'},
+		{0x08, '�'},
+		{0x1F, '�'},
+		{0xD800, '�'},
+		{0xFFFE, '�'},
+		{0xFFFD, 0xFFFD},
+		{0x1F600, 0x1F600},
+	}
+
+	for _, tt := range tests {
+		if got := replaceInvalidXML(tt.r); got != tt.want {
+			t.Errorf("replaceInvalidXML(%U) = %U, want %U", tt.r, got, tt.want)
+		}
+	}
+}
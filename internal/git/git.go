@@ -0,0 +1,348 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Repo runs git commands against a local repository
+type Repo struct {
+	Root string
+}
+
+// Open finds the repository containing dir
+func Open(dir string) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git executable not found: %w", err)
+	}
+
+	out, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %s", dir)
+	}
+
+	return &Repo{Root: filepath.FromSlash(strings.TrimSpace(string(out)))}, nil
+}
+
+// run executes git in dir and returns its standard output
+func run(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("git %s: %s", strings.Join(args, " "), message)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// run executes git at the repository root
+func (r *Repo) run(args ...string) ([]byte, error) {
+	return run(r.Root, args...)
+}
+
+// splitNull splits NUL-terminated output from commands run with -z
+func splitNull(out []byte) []string {
+	var paths []string
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// ResolveRef checks that ref names a commit
+func (r *Repo) ResolveRef(ref string) error {
+	if _, err := r.run("rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return fmt.Errorf("unknown revision '%s'", ref)
+	}
+	return nil
+}
+
+// hasHead reports whether the repository has at least one commit
+func (r *Repo) hasHead() bool {
+	return r.ResolveRef("HEAD") == nil
+}
+
+// TrackedFiles returns the paths in the index, relative to the root
+func (r *Repo) TrackedFiles() ([]string, error) {
+	out, err := r.run("ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return splitNull(out), nil
+}
+
+// ChangedFiles returns staged, unstaged and untracked paths, relative to the root.
+// Deleted files are left out.
+func (r *Repo) ChangedFiles() ([]string, error) {
+	var changed []byte
+	var err error
+	if r.hasHead() {
+		changed, err = r.run("diff", "--name-only", "-z", "--diff-filter=d", "HEAD", "--")
+	} else {
+		// Nothing is committed yet, so everything in the index is a change
+		changed, err = r.run("ls-files", "-z")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := r.run("ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(splitNull(changed), splitNull(untracked)...), nil
+}
+
+// ChangedSince returns the paths that differ between ref and the working tree,
+// relative to the root. Deleted files are left out.
+func (r *Repo) ChangedSince(ref string) ([]string, error) {
+	if err := r.ResolveRef(ref); err != nil {
+		return nil, err
+	}
+
+	out, err := r.run("diff", "--name-only", "-z", "--diff-filter=d", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	return splitNull(out), nil
+}
+
+// Diff returns a unified diff between base and target for the given paths,
+// which are relative to the root. An empty target diffs against the working tree,
+// in which case untracked paths are diffed as new files.
+func (r *Repo) Diff(base, target string, paths []string, context int) (string, error) {
+	if err := r.ResolveRef(base); err != nil {
+		return "", err
+	}
+	if target != "" {
+		if err := r.ResolveRef(target); err != nil {
+			return "", err
+		}
+	}
+	if len(paths) == 0 {
+		return "", nil
+	}
+
+	unified := fmt.Sprintf("--unified=%d", context)
+	args := []string{"--literal-pathspecs", "diff", "--no-color", "--no-ext-diff", unified, base}
+	if target != "" {
+		args = append(args, target)
+	}
+
+	// git diff sorts its output by path, so diffing sorted batches in order
+	// gives the same result as a single call
+	paths = append([]string(nil), paths...)
+	sort.Strings(paths)
+	batches := pathBatches(paths)
+
+	var result strings.Builder
+	for _, batch := range batches {
+		out, err := r.run(append(append(args, "--"), batch...)...)
+		if err != nil {
+			return "", err
+		}
+		result.Write(out)
+	}
+	if target != "" {
+		return result.String(), nil
+	}
+
+	var untracked []string
+	for _, batch := range batches {
+		out, err := r.run(append([]string{"--literal-pathspecs", "ls-files", "-z", "--others", "--exclude-standard", "--"}, batch...)...)
+		if err != nil {
+			return "", err
+		}
+		untracked = append(untracked, splitNull(out)...)
+	}
+	for _, path := range untracked {
+		out, err := r.newFileDiff(path, unified)
+		if err != nil {
+			return "", err
+		}
+		result.Write(out)
+	}
+
+	return result.String(), nil
+}
+
+// maxPathspecBytes bounds the pathspec arguments of a single git command,
+// well below ARG_MAX and the 32K command line limit of Windows
+const maxPathspecBytes = 16 * 1024
+
+// pathBatches splits paths into batches that can be passed as pathspec
+// arguments without exceeding the command line length limit. git diff and
+// git ls-files don't support --pathspec-from-file.
+func pathBatches(paths []string) [][]string {
+	var batches [][]string
+	start, size := 0, 0
+	for i, path := range paths {
+		if i > start && size+len(path)+1 > maxPathspecBytes {
+			batches = append(batches, paths[start:i])
+			start, size = i, 0
+		}
+		size += len(path) + 1
+	}
+	if start < len(paths) {
+		batches = append(batches, paths[start:])
+	}
+	return batches
+}
+
+// newFileDiff diffs an untracked file against an empty file
+func (r *Repo) newFileDiff(path, unified string) ([]byte, error) {
+	cmd := exec.Command("git", "-C", r.Root, "diff", "--no-color", "--no-ext-diff", "--no-index", unified, "--", os.DevNull, path)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// git diff --no-index exits with 1 when the files differ
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+			return nil, fmt.Errorf("git diff --no-index %s: %s", path, strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// TreeEntry is a file in a git tree
+type TreeEntry struct {
+	Path string // Path relative to the root
+	Size int64
+}
+
+// TreeFiles lists the regular files in the tree of rev
+func (r *Repo) TreeFiles(rev string) ([]TreeEntry, error) {
+	if err := r.ResolveRef(rev); err != nil {
+		return nil, err
+	}
+
+	out, err := r.run("ls-tree", "-r", "-l", "-z", "--full-tree", rev)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []TreeEntry
+	for _, line := range splitNull(out) {
+		// Format: <mode> SP <type> SP <object> SP+ <size> TAB <path>
+		meta, path, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 4 || fields[1] != "blob" || fields[0] == "120000" {
+			continue // Skip submodules and symlinks
+		}
+
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, TreeEntry{Path: path, Size: size})
+	}
+
+	return entries, nil
+}
+
+// ReadFile returns the content of path, relative to the root, at rev
+func (r *Repo) ReadFile(rev, path string) ([]byte, error) {
+	return r.run("cat-file", "blob", rev+":"+path)
+}
+
+// BlobReader reads the start of many files through a single git cat-file --batch process
+type BlobReader struct {
+	repo   *Repo
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// NewBlobReader starts a cat-file process for the repository. Close stops it.
+func (r *Repo) NewBlobReader() (*BlobReader, error) {
+	cmd := exec.Command("git", "-C", r.Root, "cat-file", "--batch")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file --batch: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file --batch: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("git cat-file --batch: %w", err)
+	}
+
+	return &BlobReader{repo: r, cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// ReadHead returns at most n bytes from the start of path, relative to the
+// root, at rev. The rest of the content is skipped without being kept.
+func (b *BlobReader) ReadHead(rev, path string, n int) ([]byte, error) {
+	if strings.ContainsAny(path, "\n\r") {
+		// Batch input is line based
+		data, err := b.repo.ReadFile(rev, path)
+		if len(data) > n {
+			data = data[:n]
+		}
+		return data, err
+	}
+
+	if _, err := fmt.Fprintf(b.stdin, "%s:%s\n", rev, path); err != nil {
+		return nil, fmt.Errorf("git cat-file --batch: %w", err)
+	}
+
+	// Header: <object> SP <type> SP <size> LF, or <name> SP missing LF
+	header, err := b.stdout.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file --batch: %w", err)
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("git cat-file %s:%s: %s", rev, path, strings.TrimSpace(header))
+	}
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file %s:%s: invalid size '%s'", rev, path, fields[2])
+	}
+
+	head := make([]byte, min(int64(n), size))
+	if _, err := io.ReadFull(b.stdout, head); err != nil {
+		return nil, fmt.Errorf("git cat-file %s:%s: %w", rev, path, err)
+	}
+	// Skip the rest of the content and the LF after it
+	if _, err := b.stdout.Discard(int(size-int64(len(head))) + 1); err != nil {
+		return nil, fmt.Errorf("git cat-file %s:%s: %w", rev, path, err)
+	}
+
+	if fields[1] != "blob" {
+		return nil, fmt.Errorf("git cat-file %s:%s: not a file", rev, path)
+	}
+	return head, nil
+}
+
+// Close stops the cat-file process
+func (b *BlobReader) Close() error {
+	b.stdin.Close()
+	return b.cmd.Wait()
+}
@@ -0,0 +1,107 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPathBatches(t *testing.T) {
+	if got := pathBatches(nil); got != nil {
+		t.Errorf("pathBatches(nil) = %q, want nil", got)
+	}
+
+	small := []string{"a.go", "b.go"}
+	if got := pathBatches(small); !reflect.DeepEqual(got, [][]string{small}) {
+		t.Errorf("pathBatches(%q) = %q, want a single batch", small, got)
+	}
+
+	long := strings.Repeat("x", maxPathspecBytes/3)
+	paths := []string{long + "1", long + "2", long + "3", long + "4", "short"}
+	batches := pathBatches(paths)
+	var joined []string
+	for _, batch := range batches {
+		size := 0
+		for _, path := range batch {
+			size += len(path) + 1
+		}
+		if size > maxPathspecBytes {
+			t.Errorf("batch of %d bytes exceeds %d", size, maxPathspecBytes)
+		}
+		joined = append(joined, batch...)
+	}
+	if len(batches) < 2 || !reflect.DeepEqual(joined, paths) {
+		t.Errorf("pathBatches split %d paths into %d batches: %q", len(paths), len(batches), batches)
+	}
+
+	// A single path longer than the limit still gets its own batch
+	huge := []string{strings.Repeat("y", maxPathspecBytes+1)}
+	if got := pathBatches(huge); len(got) != 1 || len(got[0]) != 1 {
+		t.Errorf("pathBatches of an oversized path = %d batches", len(got))
+	}
+}
+
+func TestBlobReaderReadHead(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "test"},
+	} {
+		if _, err := run(dir, args...); err != nil {
+			t.Skip(err)
+		}
+	}
+	large := strings.Repeat("0123456789", 2000)
+	if err := os.WriteFile(filepath.Join(dir, "large.txt"), []byte(large), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "small.txt"), []byte("hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"add", "."}, {"commit", "-q", "-m", "init"}} {
+		if _, err := run(dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := repo.NewBlobReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blobs.Close()
+
+	// Reads after a truncated one start at the next object
+	tests := []struct {
+		path string
+		n    int
+		want string
+	}{
+		{"large.txt", 15, large[:15]},
+		{"small.txt", 100, "hi\n"},
+		{"large.txt", 30000, large},
+		{"small.txt", 2, "hi"},
+	}
+	for _, tt := range tests {
+		got, err := blobs.ReadHead("HEAD", tt.path, tt.n)
+		if err != nil {
+			t.Fatalf("ReadHead(%s, %d): %v", tt.path, tt.n, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("ReadHead(%s, %d) = %d bytes, want %d", tt.path, tt.n, len(got), len(tt.want))
+		}
+	}
+
+	if _, err := blobs.ReadHead("HEAD", "missing.txt", 10); err == nil {
+		t.Error("ReadHead of a missing file succeeded")
+	}
+	if got, err := blobs.ReadHead("HEAD", "small.txt", 10); err != nil || string(got) != "hi\n" {
+		t.Errorf("ReadHead after a missing file = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,71 @@
+package gomod
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Module is a Go module, found by the go.mod in its root directory
+type Module struct {
+	Root string // Directory of go.mod, empty if there is none
+	Path string // Module path declared in go.mod
+}
+
+// Finder finds the module a directory belongs to, caching results by
+// directory
+type Finder struct {
+	readFile func(path string) ([]byte, error)
+	modules  map[string]Module
+}
+
+// NewFinder creates a Finder that reads go.mod files with readFile, so they
+// can come from the working tree or from a revision
+func NewFinder(readFile func(path string) ([]byte, error)) *Finder {
+	return &Finder{readFile: readFile, modules: make(map[string]Module)}
+}
+
+// Find returns the module of the go.mod closest above dir
+func (f *Finder) Find(dir string) Module {
+	if module, ok := f.modules[dir]; ok {
+		return module
+	}
+
+	var module Module
+	if data, err := f.readFile(filepath.Join(dir, "go.mod")); err == nil {
+		if path := ModulePath(data); path != "" {
+			module = Module{Root: dir, Path: path}
+		}
+	} else if parent := filepath.Dir(dir); parent != dir {
+		module = f.Find(parent)
+	}
+
+	f.modules[dir] = module
+	return module
+}
+
+// Dir returns the directory of the package importPath if it's part of the
+// module
+func (m Module) Dir(importPath string) (string, bool) {
+	if m.Path == "" {
+		return "", false
+	}
+	if importPath == m.Path {
+		return m.Root, true
+	}
+	if rest, ok := strings.CutPrefix(importPath, m.Path+"/"); ok {
+		return filepath.Join(m.Root, filepath.FromSlash(rest)), true
+	}
+	return "", false
+}
+
+// ModulePath returns the module path declared by the content of a go.mod
+// file, or "" if there is none
+func ModulePath(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], "\"`")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package gomod
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModulePath(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"module example.com/m\n\ngo 1.22\n", "example.com/m"},
+		{"// comment\nmodule \"example.com/quoted\"\n", "example.com/quoted"},
+		{"module example.com/m // trailing comment\n", "example.com/m"},
+		{"go 1.22\n", ""},
+	}
+	for _, tt := range tests {
+		if got := ModulePath([]byte(tt.content)); got != tt.want {
+			t.Errorf("ModulePath(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestFinderFind(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/m\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(root, "tools")
+	if err := os.MkdirAll(filepath.Join(nested, "cmd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nested, "go.mod"), []byte("module example.com/tools\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	finder := NewFinder(os.ReadFile)
+	if got := finder.Find(filepath.Join(root, "internal", "p")); got != (Module{root, "example.com/m"}) {
+		t.Errorf("Find(internal/p) = %+v, want the root module", got)
+	}
+	if got := finder.Find(filepath.Join(nested, "cmd")); got != (Module{nested, "example.com/tools"}) {
+		t.Errorf("Find(tools/cmd) = %+v, want the nested module", got)
+	}
+}
+
+func TestModuleDir(t *testing.T) {
+	module := Module{Root: filepath.FromSlash("/src/m"), Path: "example.com/m"}
+	tests := []struct {
+		importPath string
+		want       string
+		ok         bool
+	}{
+		{"example.com/m", "/src/m", true},
+		{"example.com/m/internal/p", "/src/m/internal/p", true},
+		{"example.com/mod", "", false},
+		{"fmt", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := module.Dir(tt.importPath)
+		if got != filepath.FromSlash(tt.want) || ok != tt.ok {
+			t.Errorf("Dir(%q) = %q, %v, want %q, %v", tt.importPath, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Messages holds the user-visible strings of one language.
+// Strings with format verbs are used with fmt.Sprintf.
+type Messages struct {
+	// Generated prompt
+	PromptHeading       string
+	DefaultPromptBefore string
+	DefaultPromptAfter  string
+	FilesBegin          string
+	FilesEnd            string
+	FileHeader          string // %s: file path
+	DiffBegin           string
+	DiffEnd             string
+	FilesHeading        string // Markdown heading of the file contents
+	DiffHeading         string // Markdown heading of the diff
+	TreeHeading         string
+	TreeUnselected      string // Marks files in the tree that aren't in the prompt
+	BinaryPlaceholder   string // %s: path, %s: size, %s: MIME type
+	SkeletonNote        string // Marks files rendered as declarations only
+
+	// Dry-run summary
+	SummaryTitle  string
+	SummaryTotal  string // %d: file count, %s: total size
+	SummaryPrompt string // %s: prompt
+
+	// Token counts
+	SummaryFileTokens  string // %d: index, %s: path, %s: size, %d: tokens
+	SummaryTotalTokens string // %d: file count, %s: total size, %d: tokens, %s: encoding
+	TokenReport        string // %s: encoding
+	TokenTotal         string // %d: tokens in files, %d: tokens in the output
+
+	// Token budget
+	TruncatedLines string // %d: lines removed
+	TruncatedLine  string // %d: bytes removed from a long line
+	BudgetCuts     string // %d: file count, %d: budget
+	CutDropped     string
+	CutTruncated   string // %d: lines removed, %d: lines
+
+	// Output split into parts
+	PartNote     string // %d: part, %d: part count
+	PartNoteLast string // %d: part, %d: part count
+	LineRange    string // %d: first line, %d: last line
+	PartCopied   string // %d: part, %d: part count
+	PressEnter   string
+
+	// Formatter progress
+	ProcessingFiles  string
+	FailedToReadFile string // %s: path, %v: error
+	SkippingEmpty    string // %s: path
+	Transcoded       string // %s: path, %s: encoding
+	SkeletonFailed   string // %s: path, %v: error
+	FailedToParse    string // %s: path, %v: error
+	NotSelected      string // %s: import path
+	ProcessedFiles   string // %d: file count, %s: total size
+
+	// File selection
+	WalkedDir          string // %s: directory, %s: pattern, %d: dirs, %d: files, %d: pruned, %s: duration
+	ImportedFiles      string // %d: file count, %d: depth
+	PairedFiles        string // %d: file count
+	ExcludedTooLarge   string // %d: size limit in bytes
+	ExcludedUnreadable string // %v: error
+	ExcludedBinary     string // %s: MIME type
+
+	// Interactive mode
+	AskPrompt          string
+	AskFilePatterns    string
+	AskExcludePatterns string
+	FoundFiles         string // %d: file count
+	AskSelectFiles     string
+	InvalidIndex       string // %s: input
+	IndexOutOfRange    string // %d: index
+	AskOutputPath      string
+
+	// Command line
+	NoFilesSpecified string
+	NoFilesFound     string
+	NoFilesMatching  string
+	SkippedFiles     string // %d: file count
+	FormattingFiles  string // %d: file count
+	PromptGenerated  string // %d: file count
+	ProjectRoot      string // %s: root
+	NoExclusions     string
+	ExcludedPaths    string // %d: path count
+	ClipboardFailed  string // %v: error
+	FallbackToFile   string // %s: file name
+	ClipboardCopied  string
+	ClipboardMissing string // %s: file name
+	WrittenToFile    string // %s: file name
+}
+
+// English messages, also used when no supported language is configured
+var English = &Messages{
+	PromptHeading:       "User Request",
+	DefaultPromptBefore: "Please analyze the following code files.",
+	DefaultPromptAfter:  "Please analyze the code files above.",
+	FilesBegin:          "Files Begin",
+	FilesEnd:            "Files End",
+	FileHeader:          "File: %s",
+	DiffBegin:           "Changes Begin",
+	DiffEnd:             "Changes End",
+	FilesHeading:        "Files",
+	DiffHeading:         "Changes",
+	TreeHeading:         "Project Structure",
+	TreeUnselected:      "not selected",
+	BinaryPlaceholder:   "[binary file: %s, %s, %s]",
+	SkeletonNote:        "declarations only",
+
+	SummaryTitle:  "Files to be processed:",
+	SummaryTotal:  "Total: %d files, %s",
+	SummaryPrompt: "Prompt: %s",
+
+	SummaryFileTokens:  "%d. %s (%s, %d tokens)",
+	SummaryTotalTokens: "Total: %d files, %s, %d tokens (%s)",
+	TokenReport:        "Tokens per file (%s):",
+	TokenTotal:         "Total: %d tokens in files, %d in the generated prompt",
+
+	TruncatedLines: "[truncated %d lines]",
+	TruncatedLine:  "… [truncated %d bytes]",
+	BudgetCuts:     "Cut %d files to fit the budget of %d tokens:",
+	CutDropped:     "dropped",
+	CutTruncated:   "truncated %d of %d lines",
+
+	PartNote:     "This is part %d of %d. Reply only with \"OK\" and wait for the remaining parts.",
+	PartNoteLast: "This is the last part (%d of %d); all files have been sent.",
+	LineRange:    "lines %d-%d",
+	PartCopied:   "Part %d of %d copied to clipboard",
+	PressEnter:   "Press Enter to copy the next part...",
+
+	ProcessingFiles:  "Processing files...",
+	FailedToReadFile: "Warning: Failed to read file %s: %v",
+	SkippingEmpty:    "Skipping empty file: %s",
+	Transcoded:       "Decoded %s as %s",
+	SkeletonFailed:   "Warning: Failed to outline %s, including it in full: %v",
+	FailedToParse:    "Warning: Failed to parse %s: %v",
+	NotSelected:      "Warning: No files of imported package %s are selected, its declarations are not followed",
+	ProcessedFiles:   "Processed %d files, total size: %s",
+
+	WalkedDir:          "Walked %s for '%s': %d dirs, %d files, %d pruned in %s",
+	ImportedFiles:      "Imported %d files at depth %d",
+	PairedFiles:        "Paired %d test and source files",
+	ExcludedTooLarge:   "larger than max size (%d bytes)",
+	ExcludedUnreadable: "unreadable (%v)",
+	ExcludedBinary:     "binary file (%s)",
+
+	AskPrompt:          "Describe what you need (multiple lines, finish with an empty line):",
+	AskFilePatterns:    "Enter file patterns (e.g. *.go, src/**/*.js, finish with an empty line):",
+	AskExcludePatterns: "Enter exclude patterns (e.g. vendor/, *_test.go, finish with an empty line):",
+	FoundFiles:         "Found %d files:",
+	AskSelectFiles:     "Select files to include (numbers separated by spaces, Enter/a/all for all): ",
+	InvalidIndex:       "Warning: Invalid index '%s', skipping",
+	IndexOutOfRange:    "Warning: Index %d out of range, skipping",
+	AskOutputPath:      "Output file path (Enter to use the clipboard): ",
+
+	NoFilesSpecified: "No files specified, using current directory pattern",
+	NoFilesFound:     "No files found matching the patterns",
+	NoFilesMatching:  "No files found matching the patterns:",
+	SkippedFiles:     "Warning: %d files were skipped (not readable or not regular files)",
+	FormattingFiles:  "Formatting %d files...",
+	PromptGenerated:  "Prompt generated successfully with %d files",
+	ProjectRoot:      "Project root: %s",
+	NoExclusions:     "No files were excluded",
+	ExcludedPaths:    "Excluded %d paths:",
+	ClipboardFailed:  "Warning: Failed to copy to clipboard: %v",
+	FallbackToFile:   "Writing to file '%s' instead",
+	ClipboardCopied:  "Content copied to clipboard successfully",
+	ClipboardMissing: "Clipboard not supported, writing to file '%s'",
+	WrittenToFile:    "Content written to file: %s",
+}
+
+// Chinese messages
+var Chinese = &Messages{
+	PromptHeading:       "用户需求",
+	DefaultPromptBefore: "请分析以下代码文件。",
+	DefaultPromptAfter:  "请分析以上代码文件。",
+	FilesBegin:          "文件内容开始",
+	FilesEnd:            "文件内容结束",
+	FileHeader:          "文件: %s",
+	DiffBegin:           "代码变更开始",
+	DiffEnd:             "代码变更结束",
+	FilesHeading:        "文件内容",
+	DiffHeading:         "代码变更",
+	TreeHeading:         "项目结构",
+	TreeUnselected:      "未选择",
+	BinaryPlaceholder:   "[二进制文件: %s, %s, %s]",
+	SkeletonNote:        "仅声明",
+
+	SummaryTitle:  "将要处理的文件:",
+	SummaryTotal:  "共 %d 个文件，%s",
+	SummaryPrompt: "提示词: %s",
+
+	SummaryFileTokens:  "%d. %s (%s，%d 个 token)",
+	SummaryTotalTokens: "共 %d 个文件，%s，%d 个 token (%s)",
+	TokenReport:        "各文件 token 数 (%s):",
+	TokenTotal:         "文件共 %d 个 token，生成的 Prompt 共 %d 个 token",
+
+	TruncatedLines: "[已截断 %d 行]",
+	TruncatedLine:  "… [已截断 %d 字节]",
+	BudgetCuts:     "为满足 %[2]d 个 token 的预算，裁剪了 %[1]d 个文件:",
+	CutDropped:     "已移除",
+	CutTruncated:   "截断了 %d 行（共 %d 行）",
+
+	PartNote:     "这是第 %d 部分，共 %d 部分。请只回复“OK”，等待剩余部分。",
+	PartNoteLast: "这是最后一部分（第 %d 部分，共 %d 部分），所有文件已发送完毕。",
+	LineRange:    "第 %d-%d 行",
+	PartCopied:   "第 %d 部分（共 %d 部分）已复制到剪贴板",
+	PressEnter:   "按回车键复制下一部分...",
+
+	ProcessingFiles:  "正在处理文件...",
+	FailedToReadFile: "警告: 读取文件 %s 失败: %v",
+	SkippingEmpty:    "跳过空文件: %s",
+	Transcoded:       "已按 %[2]s 编码读取 %[1]s",
+	SkeletonFailed:   "警告: 无法提取 %s 的声明，将包含完整内容: %v",
+	FailedToParse:    "警告: 解析文件 %s 失败: %v",
+	NotSelected:      "警告: 导入的包 %s 没有文件被选中，不会追加其中的声明",
+	ProcessedFiles:   "已处理 %d 个文件，总大小: %s",
+
+	WalkedDir:          "遍历 %s 查找 '%s': %d 个目录, %d 个文件, 跳过 %d 个目录, 用时 %s",
+	ImportedFiles:      "在第 %[2]d 层导入了 %[1]d 个文件",
+	PairedFiles:        "配对了 %d 个测试文件和源文件",
+	ExcludedTooLarge:   "超过大小限制 (%d 字节)",
+	ExcludedUnreadable: "无法读取 (%v)",
+	ExcludedBinary:     "二进制文件 (%s)",
+
+	AskPrompt:          "请输入功能描述 (多行输入，空行结束):",
+	AskFilePatterns:    "请输入文件模式 (如: *.go, src/**/*.js, 空行结束):",
+	AskExcludePatterns: "请输入排除模式 (如: vendor/, *_test.go, 空行结束):",
+	FoundFiles:         "找到 %d 个文件:",
+	AskSelectFiles:     "请选择要包含的文件 (输入编号，用空格分隔，Enter/a/all 以选择全部): ",
+	InvalidIndex:       "警告: 无效的编号 '%s'，已跳过",
+	IndexOutOfRange:    "警告: 编号 %d 超出范围，已跳过",
+	AskOutputPath:      "输出文件路径 (回车使用剪贴板): ",
+
+	NoFilesSpecified: "未指定文件，使用当前目录模式",
+	NoFilesFound:     "没有找到匹配模式的文件",
+	NoFilesMatching:  "没有找到匹配以下模式的文件:",
+	SkippedFiles:     "警告: 跳过了 %d 个文件（不可读或不是普通文件）",
+	FormattingFiles:  "正在格式化 %d 个文件...",
+	PromptGenerated:  "已成功生成包含 %d 个文件的 Prompt",
+	ProjectRoot:      "项目根目录: %s",
+	NoExclusions:     "没有文件被排除",
+	ExcludedPaths:    "排除了 %d 个路径:",
+	ClipboardFailed:  "警告: 复制到剪贴板失败: %v",
+	FallbackToFile:   "改为写入文件 '%s'",
+	ClipboardCopied:  "内容已成功复制到剪贴板",
+	ClipboardMissing: "不支持剪贴板，写入文件 '%s'",
+	WrittenToFile:    "内容已写入文件: %s",
+}
+
+// Japanese messages
+var Japanese = &Messages{
+	PromptHeading:       "ユーザーの要望",
+	DefaultPromptBefore: "以下のコードファイルを分析してください。",
+	DefaultPromptAfter:  "以上のコードファイルを分析してください。",
+	FilesBegin:          "ファイル内容ここから",
+	FilesEnd:            "ファイル内容ここまで",
+	FileHeader:          "ファイル: %s",
+	DiffBegin:           "変更内容ここから",
+	DiffEnd:             "変更内容ここまで",
+	FilesHeading:        "ファイル内容",
+	DiffHeading:         "変更内容",
+	TreeHeading:         "プロジェクト構成",
+	TreeUnselected:      "未選択",
+	BinaryPlaceholder:   "[バイナリファイル: %s, %s, %s]",
+	SkeletonNote:        "宣言のみ",
+
+	SummaryTitle:  "処理対象のファイル:",
+	SummaryTotal:  "合計: %d ファイル、%s",
+	SummaryPrompt: "プロンプト: %s",
+
+	SummaryFileTokens:  "%d. %s (%s、%d トークン)",
+	SummaryTotalTokens: "合計: %d ファイル、%s、%d トークン (%s)",
+	TokenReport:        "ファイルごとのトークン数 (%s):",
+	TokenTotal:         "合計: ファイル %d トークン、生成したプロンプト %d トークン",
+
+	TruncatedLines: "[%d 行を省略]",
+	TruncatedLine:  "… [%d バイト省略]",
+	BudgetCuts:     "%[2]d トークンの予算に収めるため %[1]d ファイルを削りました:",
+	CutDropped:     "除外",
+	CutTruncated:   "%[2]d 行中 %[1]d 行を省略",
+
+	PartNote:     "これは %d/%d 番目のパートです。「OK」とだけ返信し、残りのパートを待ってください。",
+	PartNoteLast: "これは最後のパート (%d/%d) です。すべてのファイルを送信しました。",
+	LineRange:    "%d-%d 行目",
+	PartCopied:   "パート %d/%d をクリップボードにコピーしました",
+	PressEnter:   "Enter キーを押すと次のパートをコピーします...",
+
+	ProcessingFiles:  "ファイルを処理中...",
+	FailedToReadFile: "警告: ファイル %s を読み込めませんでした: %v",
+	SkippingEmpty:    "空のファイルをスキップ: %s",
+	Transcoded:       "%s を %s として読み込みました",
+	SkeletonFailed:   "警告: %s の宣言を抽出できませんでした。全文を含めます: %v",
+	FailedToParse:    "警告: ファイル %s を解析できませんでした: %v",
+	NotSelected:      "警告: インポートされたパッケージ %s のファイルが選択されていないため、その宣言は追加されません",
+	ProcessedFiles:   "%d ファイルを処理しました。合計サイズ: %s",
+
+	WalkedDir:          "%s を '%s' で走査: ディレクトリ %d 個, ファイル %d 個, %d 個をスキップ, %s",
+	ImportedFiles:      "深さ %[2]d で %[1]d 個のファイルをインポートしました",
+	PairedFiles:        "%d 個のテストファイルとソースファイルを追加しました",
+	ExcludedTooLarge:   "サイズ上限を超えています (%d バイト)",
+	ExcludedUnreadable: "読み取れません (%v)",
+	ExcludedBinary:     "バイナリファイル (%s)",
+
+	AskPrompt:          "要望を入力してください (複数行可、空行で終了):",
+	AskFilePatterns:    "ファイルパターンを入力してください (例: *.go, src/**/*.js、空行で終了):",
+	AskExcludePatterns: "除外パターンを入力してください (例: vendor/, *_test.go、空行で終了):",
+	FoundFiles:         "%d 個のファイルが見つかりました:",
+	AskSelectFiles:     "含めるファイルを選択してください (番号をスペース区切りで入力、Enter/a/all ですべて選択): ",
+	InvalidIndex:       "警告: 無効な番号 '%s' をスキップします",
+	IndexOutOfRange:    "警告: 番号 %d は範囲外のためスキップします",
+	AskOutputPath:      "出力ファイルのパス (Enter でクリップボードを使用): ",
+
+	NoFilesSpecified: "ファイルが指定されていないため、カレントディレクトリのパターンを使用します",
+	NoFilesFound:     "パターンに一致するファイルが見つかりません",
+	NoFilesMatching:  "次のパターンに一致するファイルが見つかりません:",
+	SkippedFiles:     "警告: %d 個のファイルをスキップしました (読み込めないか通常のファイルではありません)",
+	FormattingFiles:  "%d 個のファイルを整形中...",
+	PromptGenerated:  "%d 個のファイルを含むプロンプトを生成しました",
+	ProjectRoot:      "プロジェクトルート: %s",
+	NoExclusions:     "除外されたファイルはありません",
+	ExcludedPaths:    "%d 個のパスを除外しました:",
+	ClipboardFailed:  "警告: クリップボードへのコピーに失敗しました: %v",
+	FallbackToFile:   "代わりにファイル '%s' に書き込みます",
+	ClipboardCopied:  "クリップボードにコピーしました",
+	ClipboardMissing: "クリップボードが使用できないため、ファイル '%s' に書き込みます",
+	WrittenToFile:    "ファイルに書き込みました: %s",
+}
+
+// Lookup returns the messages for a language code such as "en", "zh_CN.UTF-8"
+// or "ja-JP". An empty code detects the language from the environment.
+func Lookup(lang string) (*Messages, error) {
+	if lang == "" {
+		return Detect(), nil
+	}
+
+	if messages := match(lang); messages != nil {
+		return messages, nil
+	}
+	return nil, fmt.Errorf("unknown language '%s' (expected en, zh or ja)", lang)
+}
+
+// Detect picks the language from LC_ALL, LC_MESSAGES or LANG, falling back to English
+func Detect() *Messages {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		// The first variable that is set decides, as in POSIX locale lookup
+		if messages := match(value); messages != nil {
+			return messages
+		}
+		return English
+	}
+	return English
+}
+
+// match maps a language code or locale name to its messages
+func match(lang string) *Messages {
+	// Drop the encoding and modifier of locale names like "zh_CN.UTF-8@pinyin"
+	lang, _, _ = strings.Cut(strings.ToLower(lang), ".")
+	lang, _, _ = strings.Cut(lang, "@")
+
+	switch {
+	case strings.HasPrefix(lang, "en"), lang == "c", lang == "posix":
+		return English
+	case strings.HasPrefix(lang, "zh"):
+		return Chinese
+	case strings.HasPrefix(lang, "ja"):
+		return Japanese
+	default:
+		return nil
+	}
+}
@@ -7,24 +7,38 @@ import (
 	"path/filepath"
 	"strings"
 
+	"aicodeprep-go/internal/i18n"
 	"aicodeprep-go/internal/selector"
 )
 
 // InputHandler handles interactive user input
 type InputHandler struct {
-	scanner *bufio.Scanner
+	scanner  *bufio.Scanner
+	messages *i18n.Messages
 }
 
 // New creates a new InputHandler
 func New() *InputHandler {
 	return &InputHandler{
-		scanner: bufio.NewScanner(os.Stdin),
+		scanner:  bufio.NewScanner(os.Stdin),
+		messages: i18n.English,
 	}
 }
 
+// SetMessages sets the language of the questions and warnings
+func (ih *InputHandler) SetMessages(messages *i18n.Messages) {
+	ih.messages = messages
+}
+
+// Scanner returns the scanner reading standard input, for later readers
+// that must not lose the input it has buffered
+func (ih *InputHandler) Scanner() *bufio.Scanner {
+	return ih.scanner
+}
+
 // GetPrompt gets prompt input from user interactively
 func (ih *InputHandler) GetPrompt() (string, error) {
-	fmt.Print("请输入功能描述 (多行输入，空行结束):\n> ")
+	fmt.Print(ih.messages.AskPrompt + "\n> ")
 
 	var lines []string
 	for {
@@ -51,7 +65,7 @@ func (ih *InputHandler) GetPrompt() (string, error) {
 
 // GetFilePatterns gets file patterns from user interactively
 func (ih *InputHandler) GetFilePatterns() ([]string, error) {
-	fmt.Print("请输入文件模式 (如: *.go, src/**/*.js, 空行结束):\n> ")
+	fmt.Print(ih.messages.AskFilePatterns + "\n> ")
 
 	var patterns []string
 	for {
@@ -81,7 +95,7 @@ func (ih *InputHandler) GetFilePatterns() ([]string, error) {
 
 // GetExcludePatterns gets exclude patterns from user interactively
 func (ih *InputHandler) GetExcludePatterns() ([]string, error) {
-	fmt.Print("请输入排除模式 (如: vendor/*, *_test.go, 空行结束):\n> ")
+	fmt.Print(ih.messages.AskExcludePatterns + "\n> ")
 
 	var excludes []string
 	for {
@@ -110,13 +124,13 @@ func (ih *InputHandler) SelectFromList(files []selector.FileInfo) ([]selector.Fi
 		return files, nil
 	}
 
-	fmt.Printf("\n找到 %d 个文件:\n", len(files))
+	fmt.Printf("\n"+ih.messages.FoundFiles+"\n", len(files))
 	for i, file := range files {
 		relPath := getDisplayPath(file.Path)
 		fmt.Printf("%d. %s (%s)\n", i+1, relPath, formatBytes(file.Size))
 	}
 
-	fmt.Print("\n请选择要包含的文件 (输入编号，用空格分隔，Enter/a/all 以选择全部): ")
+	fmt.Print("\n" + ih.messages.AskSelectFiles)
 
 	if !ih.scanner.Scan() {
 		if err := ih.scanner.Err(); err != nil {
@@ -137,12 +151,12 @@ func (ih *InputHandler) SelectFromList(files []selector.FileInfo) ([]selector.Fi
 	for _, part := range parts {
 		var index int
 		if _, err := fmt.Sscanf(part, "%d", &index); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Invalid index '%s', skipping\n", part)
+			fmt.Fprintf(os.Stderr, ih.messages.InvalidIndex+"\n", part)
 			continue
 		}
 
 		if index < 1 || index > len(files) {
-			fmt.Fprintf(os.Stderr, "Warning: Index %d out of range, skipping\n", index)
+			fmt.Fprintf(os.Stderr, ih.messages.IndexOutOfRange+"\n", index)
 			continue
 		}
 
@@ -154,7 +168,7 @@ func (ih *InputHandler) SelectFromList(files []selector.FileInfo) ([]selector.Fi
 
 // GetOutputPath gets output path from user interactively
 func (ih *InputHandler) GetOutputPath() (string, error) {
-	fmt.Print("输出文件路径 (回车使用剪贴板): ")
+	fmt.Print(ih.messages.AskOutputPath)
 
 	if !ih.scanner.Scan() {
 		if err := ih.scanner.Err(); err != nil {
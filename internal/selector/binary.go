@@ -0,0 +1,91 @@
+package selector
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// sniffLen is how much of a file is inspected to tell text from binary
+const sniffLen = 8192
+
+// SetBinaryPlaceholders keeps binary files in the selection, marked so the
+// formatter lists them as placeholders, instead of excluding them
+func (fs *FileSelector) SetBinaryPlaceholders(enabled bool) {
+	fs.binaryPlaceholders = enabled
+}
+
+// sniffFile inspects the start of the file at path, which is read in the
+// forced encoding if set
+func sniffFile(path, forced string) (binary bool, mime string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, "", err
+	}
+	defer file.Close()
+
+	data := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, data)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, "", err
+	}
+
+	binary, mime = sniff(data[:n], forced)
+	return binary, mime, nil
+}
+
+// sniff reports whether data, the start of a file read in the forced
+// encoding if set, is binary, along with its MIME type. The decision rests on
+// the bytes alone: text has no NUL or control bytes other than whitespace and
+// escape. The MIME type only labels placeholders, so formats that are text,
+// such as PostScript, are kept.
+func sniff(data []byte, forced string) (binary bool, mime string) {
+	mime, _, _ = strings.Cut(http.DetectContentType(data), ";")
+
+	// UTF-16 text is full of NUL bytes but is transcoded when read
+	if bytes.HasPrefix(data, []byte{0xFF, 0xFE}) || bytes.HasPrefix(data, []byte{0xFE, 0xFF}) {
+		return false, mime
+	}
+	if forced != "" && isWideEncoding(forced) {
+		return false, "text/plain"
+	}
+
+	if bytes.ContainsFunc(data, isControl) {
+		return true, mime
+	}
+
+	// UTF-8, allowing a character cut off at the end, and text in a forced or
+	// detected legacy encoding are all read as text
+	return false, mime
+}
+
+// isControl reports whether r is a control character that doesn't occur in
+// text: anything below space except tab, line feed, vertical tab, form feed,
+// carriage return and escape
+func isControl(r rune) bool {
+	switch r {
+	case '\t', '\n', '\v', '\f', '\r', 0x1B:
+		return false
+	}
+	return r < 0x20
+}
+
+// checkBinary excludes binary files unless placeholders are enabled, in which
+// case it marks them. It reports whether the file stays in the selection.
+func (fs *FileSelector) checkBinary(file *FileInfo, binary bool, mime string) bool {
+	if !binary {
+		return true
+	}
+
+	if !fs.binaryPlaceholders {
+		fs.recordExclusion(file.Path, false, fmt.Sprintf(fs.messages.ExcludedBinary, mime))
+		return false
+	}
+
+	file.Binary = true
+	file.MIME = mime
+	return true
+}
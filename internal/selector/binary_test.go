@@ -0,0 +1,50 @@
+package selector
+
+import "testing"
+
+func TestSniff(t *testing.T) {
+	utf16 := []byte{'h', 0, 'i', 0, '\n', 0}
+	tests := []struct {
+		name   string
+		data   []byte
+		forced string
+		binary bool
+	}{
+		{"text", []byte("package main\n"), "", false},
+		{"empty", nil, "", false},
+		{"nul", []byte("abc\x00def"), "", true},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "", true},
+		{"utf-16 bom", append([]byte{0xFF, 0xFE}, utf16...), "", false},
+		{"utf-16 without bom", utf16, "", true},
+		{"forced utf-16le", utf16, "utf-16le", false},
+		{"forced utf-16be", []byte{0, 'h', 0, 'i'}, "UTF-16BE", false},
+		{"forced gbk", []byte("abc\x00def"), "gbk", true},
+		{"gbk text", []byte{0xC4, 0xE3, 0xBA, 0xC3}, "", false},
+		{"utf-8 cut at the end", []byte("日本")[:4], "", false},
+		{"postscript", []byte("%!PS-Adobe-3.0\n%%Title: x\nshowpage\n"), "", false},
+		{"ansi colors", []byte("\x1b[31mred\x1b[0m\n"), "", false},
+		{"form feed", []byte("page 1\f\npage 2\n"), "", false},
+		{"gzip", []byte("\x1f\x8b\x08\x08abc"), "", true},
+		{"control bytes", []byte("abc\x01\x02def"), "", true},
+	}
+
+	for _, tt := range tests {
+		if binary, mime := sniff(tt.data, tt.forced); binary != tt.binary {
+			t.Errorf("%s: sniff = %v (%s), want %v", tt.name, binary, mime, tt.binary)
+		}
+	}
+}
+
+func TestSelectForcedUTF16(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "leg/u16.txt", "h\x00i\x00\n\x00")
+	writeFile(t, root, "leg/blob.bin", "h\x00i\x00\n\x00")
+
+	fs := newTestSelector(t, root, "leg/*")
+	fs.SetEncodings(map[string]string{"leg/*.txt": "utf-16le"})
+
+	files, paths := selectFiles(t, fs)
+	if len(files) != 1 || paths[0] != "leg/u16.txt" || files[0].Binary || files[0].Encoding != "utf-16le" {
+		t.Errorf("selected %+v, want leg/u16.txt as utf-16le text", files)
+	}
+}
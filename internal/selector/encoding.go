@@ -0,0 +1,54 @@
+package selector
+
+import (
+	"os"
+	"sort"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// SetEncodings forces the encoding of files matching each glob, e.g.
+// {"legacy/**/*.c": "gbk"}. Globs are relative to the working directory;
+// when several match, the longest one wins.
+func (fs *FileSelector) SetEncodings(encodings map[string]string) {
+	fs.encodings = encodings
+
+	fs.encodingPatterns = make([]string, 0, len(encodings))
+	for pattern := range encodings {
+		fs.encodingPatterns = append(fs.encodingPatterns, pattern)
+	}
+	sort.Slice(fs.encodingPatterns, func(i, j int) bool {
+		a, b := fs.encodingPatterns[i], fs.encodingPatterns[j]
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+}
+
+// forcedEncoding returns the encoding forced for the file at path, or ""
+// to detect it
+func (fs *FileSelector) forcedEncoding(path string) string {
+	if len(fs.encodingPatterns) == 0 {
+		return ""
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		wd = fs.root
+	}
+	if match := matchAny(fs.encodingPatterns, wd, path); match >= 0 {
+		return fs.encodings[fs.encodingPatterns[match]]
+	}
+	return ""
+}
+
+// isWideEncoding reports whether name is UTF-16, whose text is full of NUL bytes
+func isWideEncoding(name string) bool {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return false
+	}
+	canonical, _ := htmlindex.Name(enc)
+	return canonical == "utf-16le" || canonical == "utf-16be"
+}
@@ -0,0 +1,124 @@
+package selector
+
+import (
+	"path"
+	"path/filepath"
+)
+
+// Exclusion records why a path was left out of the selection
+type Exclusion struct {
+	Path   string // Path relative to the project root, directories end with "/"
+	Reason string // Rule or check that removed the path
+}
+
+// excludeMatcher evaluates exclude patterns against paths relative to the project root.
+// Patterns follow gitignore semantics: a pattern without a slash matches a name at any
+// depth, a slash anchors it to the root, a trailing slash matches directories only and
+// a leading "!" re-includes a previously excluded path.
+type excludeMatcher struct {
+	root        string
+	rules       ruleSet
+	hasNegation bool
+	dirCache    map[string]*ignoreRule
+}
+
+// newExcludeMatcher compiles exclude patterns relative to root
+func newExcludeMatcher(root string, patterns []string) *excludeMatcher {
+	m := &excludeMatcher{
+		root:     root,
+		dirCache: make(map[string]*ignoreRule),
+	}
+
+	for _, pattern := range patterns {
+		for _, alternative := range expandBraces(filepath.ToSlash(pattern)) {
+			if rule, ok := parseIgnoreRule(alternative, "", "exclude"); ok {
+				rule.pattern = pattern
+				m.rules = append(m.rules, rule)
+				m.hasNegation = m.hasNegation || rule.negate
+			}
+		}
+	}
+
+	return m
+}
+
+// Excluded returns the rule that excludes absPath, or nil if the path is kept
+func (m *excludeMatcher) Excluded(absPath string, isDir bool) *ignoreRule {
+	rel, ok := relativeTo(m.root, absPath)
+	if !ok {
+		// Outside the root only unanchored patterns can apply
+		var unanchored ruleSet
+		for _, rule := range m.rules {
+			if !rule.anchored {
+				unanchored = append(unanchored, rule)
+			}
+		}
+		return unanchored.match(path.Base(filepath.ToSlash(absPath)), isDir)
+	}
+
+	if rule := ignoredParent(rel, m.excludedDir); rule != nil {
+		return rule
+	}
+
+	if isDir {
+		return m.excludedDir(rel)
+	}
+	return m.rules.match(rel, false)
+}
+
+// ExcludedDir returns the rule that excludes the directory absPath and everything
+// below it, or nil if the directory has to be walked
+func (m *excludeMatcher) ExcludedDir(absPath string) *ignoreRule {
+	if rule := m.Excluded(absPath, true); rule != nil {
+		return rule
+	}
+
+	// A negated rule could re-include something below the directory
+	rel, ok := relativeTo(m.root, absPath)
+	if !ok || m.hasNegation {
+		return nil
+	}
+
+	for _, rule := range m.rules {
+		if rule.coversDir(rel) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// excludedDir evaluates a directory, caching the decision
+func (m *excludeMatcher) excludedDir(rel string) *ignoreRule {
+	if rule, ok := m.dirCache[rel]; ok {
+		return rule
+	}
+	rule := m.rules.match(rel, true)
+	m.dirCache[rel] = rule
+	return rule
+}
+
+// displayPath returns absPath relative to the root for reports,
+// or the absolute path if it lies outside the root
+func (fs *FileSelector) displayPath(absPath string, isDir bool) string {
+	rel, ok := relativeTo(fs.root, absPath)
+	if !ok {
+		rel = absPath
+	}
+	if isDir {
+		rel += "/"
+	}
+	return rel
+}
+
+// recordExclusion remembers why a path was removed for Exclusions
+func (fs *FileSelector) recordExclusion(absPath string, isDir bool, reason string) {
+	fs.exclusions = append(fs.exclusions, Exclusion{
+		Path:   fs.displayPath(absPath, isDir),
+		Reason: reason,
+	})
+}
+
+// Exclusions returns the paths removed by the last SelectFiles call and the reason for each
+func (fs *FileSelector) Exclusions() []Exclusion {
+	return fs.exclusions
+}
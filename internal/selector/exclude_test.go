@@ -0,0 +1,97 @@
+package selector
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExcludeMatcher(t *testing.T) {
+	root := filepath.FromSlash("/project")
+	tests := []struct {
+		patterns []string
+		rel      string
+		isDir    bool
+		excluded bool
+	}{
+		// A pattern with a slash is anchored at the root
+		{[]string{"build/**"}, "build/out.o", false, true},
+		{[]string{"build/**"}, "build/sub/out.o", false, true},
+		{[]string{"build/**"}, "rebuild/out.o", false, false},
+		{[]string{"build/**"}, "src/build/out.o", false, false},
+		{[]string{"**/build/**"}, "src/build/out.o", false, true},
+		{[]string{"docs/*.md"}, "docs/a.md", false, true},
+		{[]string{"docs/*.md"}, "sub/docs/a.md", false, false},
+		// A leading slash anchors a pattern without another slash
+		{[]string{"/x"}, "x", false, true},
+		{[]string{"/x"}, "sub/x", false, false},
+		{[]string{"x"}, "sub/x", false, true},
+		// A trailing slash matches directories and everything below them
+		{[]string{"vendor/"}, "vendor", true, true},
+		{[]string{"vendor/"}, "vendor", false, false},
+		{[]string{"vendor/"}, "lib/vendor/a.go", false, true},
+		{[]string{"*_test.go"}, "pkg/a_test.go", false, true},
+		// Negation re-includes, the last matching pattern wins
+		{[]string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{[]string{"*.log", "!keep.log"}, "drop.log", false, true},
+		{[]string{"*.{log,tmp}"}, "a.tmp", false, true},
+	}
+
+	for _, tt := range tests {
+		m := newExcludeMatcher(root, tt.patterns)
+		path := filepath.Join(root, filepath.FromSlash(tt.rel))
+		if got := m.Excluded(path, tt.isDir) != nil; got != tt.excluded {
+			t.Errorf("%q: Excluded(%s, dir %v) = %v, want %v", tt.patterns, tt.rel, tt.isDir, got, tt.excluded)
+		}
+	}
+}
+
+func TestExcludedDir(t *testing.T) {
+	root := filepath.FromSlash("/project")
+	tests := []struct {
+		patterns []string
+		rel      string
+		pruned   bool
+	}{
+		{[]string{"build/"}, "build", true},
+		{[]string{"build/**"}, "build", true}, // Everything below matches
+		{[]string{"build/**"}, "rebuild", false},
+		{[]string{"build/*"}, "build/sub", true},
+		{[]string{"*.go"}, "src", false},
+		{[]string{"build/**", "!build/keep.txt"}, "build", false}, // Could be re-included
+	}
+
+	for _, tt := range tests {
+		m := newExcludeMatcher(root, tt.patterns)
+		path := filepath.Join(root, filepath.FromSlash(tt.rel))
+		if got := m.ExcludedDir(path) != nil; got != tt.pruned {
+			t.Errorf("%q: ExcludedDir(%s) = %v, want %v", tt.patterns, tt.rel, got, tt.pruned)
+		}
+	}
+}
+
+func TestWalkPrunesExcludedDirs(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "main.go", "package main\n")
+	writeFile(t, root, "build/out.go", "package build\n")
+	writeFile(t, root, "build/sub/gen.go", "package sub\n")
+	writeFile(t, root, "rebuild/rebuild.go", "package rebuild\n")
+
+	fs := newTestSelector(t, root, "**/*.go")
+	fs.excludes = []string{"build/"}
+	if err := fs.SetRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	_, paths := selectFiles(t, fs)
+
+	want := []string{"main.go", "rebuild/rebuild.go"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("selected %q, want %q", paths, want)
+	}
+
+	// The directory is skipped as a whole, so nothing below it is visited
+	exclusions := fs.Exclusions()
+	if len(exclusions) != 1 || exclusions[0].Path != "build/" {
+		t.Errorf("exclusions = %+v, want only build/", exclusions)
+	}
+}
@@ -0,0 +1,199 @@
+package selector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"aicodeprep-go/internal/git"
+)
+
+// GitMode selects candidate files from the local git repository instead of globbing
+type GitMode int
+
+const (
+	GitNone    GitMode = iota // Glob the working tree
+	GitTracked                // Files in the index
+	GitChanged                // Staged, unstaged and untracked changes
+	GitSince                  // Files that differ from a ref
+)
+
+// SetGitMode builds the candidate set from git instead of globbing.
+// ref is only used by GitSince.
+func (fs *FileSelector) SetGitMode(mode GitMode, ref string) {
+	fs.gitMode = mode
+	fs.gitRef = ref
+}
+
+// SetRevision selects files from the tree of a git revision instead of the working tree
+func (fs *FileSelector) SetRevision(rev string) {
+	fs.rev = rev
+}
+
+// selectRevFiles lists the files of the revision's tree and filters them by
+// patterns, exclude rules and the size limit
+func (fs *FileSelector) selectRevFiles() ([]FileInfo, error) {
+	repo, err := git.Open(fs.root)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := repo.TreeFiles(fs.rev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files at %s: %w", fs.rev, err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	var files []FileInfo
+	for _, entry := range entries {
+		absPath := filepath.Join(repo.Root, filepath.FromSlash(entry.Path))
+		pattern := fs.patternIndex(wd, absPath)
+		if pattern < 0 {
+			continue
+		}
+
+		file, ok, err := fs.checkRevEntry(repo, entry)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			file.Pattern = pattern
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+// checkRevEntry applies exclude rules, the size limit and binary detection to
+// a file of the revision, reading only the start of its content
+func (fs *FileSelector) checkRevEntry(repo *git.Repo, entry git.TreeEntry) (FileInfo, bool, error) {
+	absPath := filepath.Join(repo.Root, filepath.FromSlash(entry.Path))
+	if rule := fs.excluder.Excluded(absPath, false); rule != nil {
+		fs.recordExclusion(absPath, false, rule.String())
+		return FileInfo{}, false, nil
+	}
+
+	if fs.maxFileSize > 0 && entry.Size > fs.maxFileSize {
+		fs.recordExclusion(absPath, false, fmt.Sprintf(fs.messages.ExcludedTooLarge, fs.maxFileSize))
+		return FileInfo{}, false, nil
+	}
+
+	file := FileInfo{
+		Path:     absPath,
+		Size:     entry.Size,
+		Rev:      fs.rev,
+		Encoding: fs.forcedEncoding(absPath),
+	}
+
+	// Check the content for binary data
+	if fs.blobs == nil {
+		blobs, err := repo.NewBlobReader()
+		if err != nil {
+			return FileInfo{}, false, err
+		}
+		fs.blobs = blobs
+	}
+	data, err := fs.blobs.ReadHead(fs.rev, entry.Path, sniffLen)
+	if err != nil {
+		return FileInfo{}, false, fmt.Errorf("failed to read %s at %s: %w", entry.Path, fs.rev, err)
+	}
+	if binary, mime := sniff(data, file.Encoding); !fs.checkBinary(&file, binary, mime) {
+		return FileInfo{}, false, nil
+	}
+
+	return file, true, nil
+}
+
+// closeBlobs stops the reader of revision files started by checkRevEntry
+func (fs *FileSelector) closeBlobs() {
+	if fs.blobs != nil {
+		fs.blobs.Close()
+		fs.blobs = nil
+	}
+}
+
+// selectGitFiles lists candidates from git and filters them by patterns,
+// exclude rules and the size limit
+func (fs *FileSelector) selectGitFiles() ([]FileInfo, error) {
+	repo, err := git.Open(fs.root)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	switch fs.gitMode {
+	case GitTracked:
+		paths, err = repo.TrackedFiles()
+	case GitChanged:
+		paths, err = repo.ChangedFiles()
+	case GitSince:
+		paths, err = repo.ChangedSince(fs.gitRef)
+	default:
+		return nil, fmt.Errorf("unknown git mode %d", fs.gitMode)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files from git: %w", err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	var files []FileInfo
+	processedFiles := make(map[string]bool) // Prevent duplicates
+	for _, path := range paths {
+		absPath := filepath.Join(repo.Root, filepath.FromSlash(path))
+		if processedFiles[absPath] {
+			continue
+		}
+		processedFiles[absPath] = true
+
+		pattern := fs.patternIndex(wd, absPath)
+		if pattern < 0 {
+			continue
+		}
+
+		// Git already decided which files belong to the candidate set
+		if file, ok := fs.checkFile(absPath, false); ok {
+			file.Pattern = pattern
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+// patternIndex returns the index of the first include pattern matching absPath,
+// or -1 if none does. No patterns match every candidate from git, so the whole
+// tree of a revision is selected, unlike the "*" default of the working tree.
+func (fs *FileSelector) patternIndex(wd, absPath string) int {
+	if len(fs.patterns) == 0 {
+		return 0
+	}
+	return matchAny(fs.patterns, wd, absPath)
+}
+
+// matchAny returns the index of the first pattern, relative to the working
+// directory or absolute, that matches absPath, or -1 if none does
+func matchAny(patterns []string, wd, absPath string) int {
+	relPath, err := filepath.Rel(wd, absPath)
+	if err != nil {
+		relPath = absPath
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	for i, pattern := range patterns {
+		pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+		if matchGlob(pattern, relPath) || matchGlob(pattern, filepath.ToSlash(absPath)) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,152 @@
+package selector
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runGit runs a git command in dir, skipping the test if git isn't available
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip(err)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+// newTestRepo creates a repository with a "base" tag and a later commit, then
+// changes the working tree:
+//
+//	base:     main.go, docs/guide.md, old.go, big.txt (10 KB)
+//	HEAD:     adds cmd/tool.go, changes docs/guide.md
+//	worktree: changes main.go, deletes old.go, shrinks big.txt, adds new.go
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	runGit(t, root, "init", "-q")
+	runGit(t, root, "config", "user.email", "test@example.com")
+	runGit(t, root, "config", "user.name", "test")
+
+	writeFile(t, root, "main.go", "package main\n")
+	writeFile(t, root, "docs/guide.md", "# Guide\n")
+	writeFile(t, root, "old.go", "package main\n\nfunc old() {}\n")
+	writeFile(t, root, "big.txt", strings.Repeat("0123456789", 1024))
+	runGit(t, root, "add", ".")
+	runGit(t, root, "commit", "-q", "-m", "base")
+	runGit(t, root, "tag", "base")
+
+	writeFile(t, root, "cmd/tool.go", "package main\n")
+	writeFile(t, root, "docs/guide.md", "# Guide\n\nMore.\n")
+	runGit(t, root, "add", ".")
+	runGit(t, root, "commit", "-q", "-m", "second")
+
+	writeFile(t, root, "main.go", "package main\n\nfunc main() {}\n")
+	if err := os.Remove(filepath.Join(root, "old.go")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, "big.txt", "small\n")
+	writeFile(t, root, "new.go", "package main\n")
+	return root
+}
+
+func TestSelectGitFiles(t *testing.T) {
+	root := newTestRepo(t)
+
+	tests := []struct {
+		name     string
+		mode     GitMode
+		ref      string
+		patterns []string
+		want     []string
+	}{
+		// Deleted files are tracked until staged, but can't be read
+		{"tracked", GitTracked, "", nil, []string{"big.txt", "cmd/tool.go", "docs/guide.md", "main.go"}},
+		{"changed", GitChanged, "", nil, []string{"big.txt", "main.go", "new.go"}},
+		{"since", GitSince, "base", nil, []string{"big.txt", "cmd/tool.go", "docs/guide.md", "main.go"}},
+		{"since filtered", GitSince, "base", []string{"**/*.go"}, []string{"cmd/tool.go", "main.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newTestSelector(t, root, tt.patterns...)
+			fs.SetGitMode(tt.mode, tt.ref)
+			_, paths := selectFiles(t, fs)
+			if !reflect.DeepEqual(paths, tt.want) {
+				t.Errorf("selected %q, want %q", paths, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectRevFiles(t *testing.T) {
+	root := newTestRepo(t)
+
+	// Without patterns the whole tree of the revision is selected
+	fs := newTestSelector(t, root)
+	fs.SetRevision("base")
+	files, paths := selectFiles(t, fs)
+	want := []string{"big.txt", "docs/guide.md", "main.go", "old.go"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("selected %q, want %q", paths, want)
+	}
+
+	// Sizes come from the revision, not the working tree
+	wantSizes := map[string]int64{"big.txt": 10240, "main.go": 13, "old.go": 28}
+	for i, file := range files {
+		if file.Rev != "base" {
+			t.Errorf("%s: Rev = %q, want base", paths[i], file.Rev)
+		}
+		if size, ok := wantSizes[paths[i]]; ok && file.Size != size {
+			t.Errorf("%s: Size = %d, want %d", paths[i], file.Size, size)
+		}
+	}
+}
+
+func TestSelectRevFilesPatterns(t *testing.T) {
+	root := newTestRepo(t)
+
+	fs := newTestSelector(t, root, "*.md", "**/*.md", "**/*.go")
+	fs.SetRevision("HEAD")
+	files, paths := selectFiles(t, fs)
+
+	want := []string{"cmd/tool.go", "docs/guide.md", "main.go", "old.go"}
+	wantPatterns := []int{2, 1, 2, 2}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("selected %q, want %q", paths, want)
+	}
+	for i, file := range files {
+		if file.Pattern != wantPatterns[i] {
+			t.Errorf("%s: Pattern = %d, want %d", paths[i], file.Pattern, wantPatterns[i])
+		}
+	}
+}
+
+func TestSelectRevFilesSizeLimit(t *testing.T) {
+	root := newTestRepo(t)
+	t.Chdir(root)
+
+	// big.txt is small in the working tree but not in the revision
+	fs := New(nil, nil, 1024)
+	if err := fs.SetRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	fs.SetRevision("HEAD")
+	_, paths := selectFiles(t, fs)
+
+	want := []string{"cmd/tool.go", "docs/guide.md", "main.go", "old.go"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("selected %q, want %q", paths, want)
+	}
+	exclusions := fs.Exclusions()
+	if len(exclusions) != 1 || exclusions[0].Path != "big.txt" || !strings.Contains(exclusions[0].Reason, "1024") {
+		t.Errorf("exclusions = %+v, want big.txt over the size limit", exclusions)
+	}
+}
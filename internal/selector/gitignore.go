@@ -0,0 +1,278 @@
+package selector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is a single compiled gitignore-style pattern
+type ignoreRule struct {
+	pattern  string // Pattern as written, used for reporting
+	source   string // Where the rule came from, e.g. ".gitignore:3"
+	base     string // Directory the rule is relative to, "" for the root
+	glob     string // Pattern segments to match, without anchors and flags
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// builtinGitRule makes the .git directory itself always ignored
+var builtinGitRule = &ignoreRule{pattern: ".git/", source: "builtin", glob: ".git", dirOnly: true}
+
+// parseIgnoreRule parses one line of a gitignore file.
+// It returns false for blank lines and comments.
+func parseIgnoreRule(line, base, source string) (*ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, false
+	}
+
+	rule := &ignoreRule{pattern: line, source: source, base: base}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	// A slash at the beginning or in the middle anchors the pattern
+	// to the directory of the ignore file
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	if line == "" {
+		return nil, false
+	}
+
+	rule.glob = line
+	return rule, true
+}
+
+// trimTrailingSpaces removes trailing spaces unless they are escaped with a backslash
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") {
+		trimmed := strings.TrimSuffix(line, " ")
+		if strings.HasSuffix(trimmed, `\`) {
+			return trimmed[:len(trimmed)-1] + " "
+		}
+		line = trimmed
+	}
+	return line
+}
+
+// matches reports whether the rule matches a slash-separated path relative to the root
+func (r *ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = strings.TrimPrefix(rel, r.base+"/")
+	}
+
+	if !r.anchored {
+		// Unanchored patterns match the name at any depth
+		return matchPathSegments(strings.Split(r.glob, "/"), []string{path.Base(rel)})
+	}
+	return matchPathSegments(strings.Split(r.glob, "/"), strings.Split(rel, "/"))
+}
+
+// coversDir reports whether the rule excludes every path below the directory rel,
+// as "build/**" or "build/*" do without matching the directory itself
+func (r *ignoreRule) coversDir(rel string) bool {
+	if r.negate || r.dirOnly || !r.anchored {
+		return false
+	}
+
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = strings.TrimPrefix(rel, r.base+"/")
+	}
+
+	segments := strings.Split(r.glob, "/")
+	last := segments[len(segments)-1]
+	if last != "**" && last != "*" {
+		return false
+	}
+	return matchPathSegments(segments[:len(segments)-1], strings.Split(rel, "/"))
+}
+
+// String describes the rule for reports
+func (r *ignoreRule) String() string {
+	return fmt.Sprintf("%s (%s)", r.pattern, r.source)
+}
+
+// ruleSet is an ordered list of ignore rules where the last matching rule wins
+type ruleSet []*ignoreRule
+
+// match returns the rule deciding that rel is ignored, or nil if it isn't
+func (rs ruleSet) match(rel string, isDir bool) *ignoreRule {
+	var decision *ignoreRule
+	for _, rule := range rs {
+		if rule.matches(rel, isDir) {
+			decision = rule
+		}
+	}
+	if decision == nil || decision.negate {
+		return nil
+	}
+	return decision
+}
+
+// ignoredParent returns the rule ignoring one of the parent directories of rel
+func ignoredParent(rel string, ignoredDir func(string) *ignoreRule) *ignoreRule {
+	segments := strings.Split(rel, "/")
+	for i := 1; i < len(segments); i++ {
+		if rule := ignoredDir(strings.Join(segments[:i], "/")); rule != nil {
+			return rule
+		}
+	}
+	return nil
+}
+
+// relativeTo converts an absolute path into a slash-separated path relative to root.
+// It returns false for paths outside root.
+func relativeTo(root, absPath string) (string, bool) {
+	rel, err := filepath.Rel(root, absPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// gitignore evaluates .gitignore, .git/info/exclude and nested .gitignore files
+// relative to a project root
+type gitignore struct {
+	root     string
+	exclude  ruleSet                // Rules from .git/info/exclude
+	perDir   map[string]ruleSet     // .gitignore rules keyed by directory relative to root
+	dirCache map[string]*ignoreRule // Decision for directories already evaluated
+}
+
+// newGitignore creates a gitignore matcher rooted at root
+func newGitignore(root string) *gitignore {
+	g := &gitignore{
+		root:     root,
+		perDir:   make(map[string]ruleSet),
+		dirCache: make(map[string]*ignoreRule),
+	}
+	g.exclude = loadIgnoreFile(filepath.Join(root, ".git", "info", "exclude"), "", ".git/info/exclude")
+	return g
+}
+
+// loadIgnoreFile reads rules from an ignore file, returning nil if it doesn't exist
+func loadIgnoreFile(filePath, base, name string) ruleSet {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules ruleSet
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		source := fmt.Sprintf("%s:%d", name, lineNumber)
+		if rule, ok := parseIgnoreRule(scanner.Text(), base, source); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// rulesFor returns the rules of the .gitignore file in dir, loading it on first use
+func (g *gitignore) rulesFor(dir string) ruleSet {
+	if rules, ok := g.perDir[dir]; ok {
+		return rules
+	}
+
+	name := ".gitignore"
+	if dir != "" {
+		name = dir + "/.gitignore"
+	}
+	rules := loadIgnoreFile(filepath.Join(g.root, filepath.FromSlash(name)), dir, name)
+	g.perDir[dir] = rules
+	return rules
+}
+
+// Ignored returns the rule that ignores absPath, or nil if the path is not ignored
+func (g *gitignore) Ignored(absPath string, isDir bool) *ignoreRule {
+	rel, ok := relativeTo(g.root, absPath)
+	if !ok {
+		return nil
+	}
+
+	// A file can't be re-included if one of its parent directories is ignored
+	if rule := ignoredParent(rel, g.ignoredDir); rule != nil {
+		return rule
+	}
+
+	if isDir {
+		return g.ignoredDir(rel)
+	}
+	return g.evaluate(rel, false)
+}
+
+// ignoredDir evaluates a directory, caching the decision
+func (g *gitignore) ignoredDir(rel string) *ignoreRule {
+	if rule, ok := g.dirCache[rel]; ok {
+		return rule
+	}
+	rule := g.evaluate(rel, true)
+	g.dirCache[rel] = rule
+	return rule
+}
+
+// evaluate applies all rules that can affect rel, the last matching rule wins
+func (g *gitignore) evaluate(rel string, isDir bool) *ignoreRule {
+	if path.Base(rel) == ".git" {
+		return builtinGitRule
+	}
+
+	rules := append(ruleSet{}, g.exclude...)
+	rules = append(rules, g.rulesFor("")...)
+	segments := strings.Split(rel, "/")
+	for i := 1; i < len(segments); i++ {
+		rules = append(rules, g.rulesFor(strings.Join(segments[:i], "/"))...)
+	}
+	return rules.match(rel, isDir)
+}
+
+// findProjectRoot returns the nearest directory containing .git, starting from
+// the working directory, or the working directory itself if there is none
+func findProjectRoot() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+
+	for dir := wd; ; {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return wd
+		}
+		dir = parent
+	}
+}
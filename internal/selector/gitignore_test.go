@@ -0,0 +1,141 @@
+package selector
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIgnoreRule(t *testing.T) {
+	tests := []struct {
+		line     string
+		ok       bool
+		glob     string
+		negate   bool
+		dirOnly  bool
+		anchored bool
+	}{
+		{"", false, "", false, false, false},
+		{"# comment", false, "", false, false, false},
+		{"*.log", true, "*.log", false, false, false},
+		{"build/", true, "build", false, true, false},
+		{"/build", true, "build", false, false, true},
+		{"docs/*.md", true, "docs/*.md", false, false, true},
+		{"!keep.log", true, "keep.log", true, false, false},
+		{`\!bang`, true, "!bang", false, false, false},
+		{`\#hash`, true, "#hash", false, false, false},
+		{"trailing   ", true, "trailing", false, false, false},
+		{`space\ `, true, "space ", false, false, false},
+		{"crlf\r", true, "crlf", false, false, false},
+		{"/", false, "", false, false, false},
+	}
+
+	for _, tt := range tests {
+		rule, ok := parseIgnoreRule(tt.line, "", "test")
+		if ok != tt.ok {
+			t.Errorf("parseIgnoreRule(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if rule.glob != tt.glob || rule.negate != tt.negate || rule.dirOnly != tt.dirOnly || rule.anchored != tt.anchored {
+			t.Errorf("parseIgnoreRule(%q) = {glob %q, negate %v, dirOnly %v, anchored %v}, want {%q, %v, %v, %v}",
+				tt.line, rule.glob, rule.negate, rule.dirOnly, rule.anchored, tt.glob, tt.negate, tt.dirOnly, tt.anchored)
+		}
+	}
+}
+
+func TestRuleSetMatch(t *testing.T) {
+	var rules ruleSet
+	for _, line := range []string{"*.log", "!keep.log", "build/", "/root.txt", "docs/**/*.tmp", "out/**"} {
+		rule, _ := parseIgnoreRule(line, "", "test")
+		rules = append(rules, rule)
+	}
+	sub, _ := parseIgnoreRule("local.txt", "sub", "sub/.gitignore:1")
+	rules = append(rules, sub)
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"a/b/app.log", false, true},
+		{"keep.log", false, false},
+		{"a/keep.log", false, false},
+		{"build", true, true},
+		{"a/build", true, true},
+		{"build", false, false},
+		{"root.txt", false, true},
+		{"a/root.txt", false, false},
+		{"docs/x.tmp", false, true},
+		{"docs/a/b/x.tmp", false, true},
+		{"a/docs/x.tmp", false, false},
+		{"out/x", false, true},
+		{"out", true, false},
+		{"sub/local.txt", false, true},
+		{"sub/deep/local.txt", false, true},
+		{"local.txt", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := rules.match(tt.rel, tt.isDir) != nil; got != tt.want {
+			t.Errorf("match(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestCoversDir(t *testing.T) {
+	tests := []struct {
+		line string
+		rel  string
+		want bool
+	}{
+		{"build/**", "build", true},
+		{"build/*", "build", true},
+		{"build/**", "a/build", false},
+		{"build", "build", false},
+		{"*.log", "logs", false},
+		{"!build/**", "build", false},
+	}
+
+	for _, tt := range tests {
+		rule, _ := parseIgnoreRule(tt.line, "", "test")
+		if got := rule.coversDir(tt.rel); got != tt.want {
+			t.Errorf("coversDir(%q, %q) = %v, want %v", tt.line, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestGitignoreNested(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, ".gitignore", "*.tmp\nvendor/\n")
+	writeFile(t, root, ".git/info/exclude", "secret.txt\n")
+	writeFile(t, root, "a/.gitignore", "!keep.tmp\n/only-here.txt\n")
+
+	g := newGitignore(root)
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"x.tmp", false, true},
+		{"a/x.tmp", false, true},
+		{"a/keep.tmp", false, false},
+		{"keep.tmp", false, true},
+		{"a/only-here.txt", false, true},
+		{"a/b/only-here.txt", false, false},
+		{"secret.txt", false, true},
+		{"vendor", true, true},
+		{"vendor/lib.go", false, true},
+		{".git", true, true},
+		{"main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		got := g.Ignored(filepath.Join(root, filepath.FromSlash(tt.rel)), tt.isDir) != nil
+		if got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package selector
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// matchGlob reports whether a slash-separated path matches a glob pattern.
+// Patterns support *, ?, character classes ([a-z], [!a-z], [^a-z]), brace
+// alternatives ({go,js}) and any number of ** segments.
+func matchGlob(pattern, name string) bool {
+	nameSegments := strings.Split(name, "/")
+	for _, alternative := range expandBraces(pattern) {
+		if matchPathSegments(strings.Split(alternative, "/"), nameSegments) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandBraces expands brace alternatives such as "*.{go,js}" into separate patterns.
+// Braces may be nested; braces without a comma are kept literally.
+func expandBraces(pattern string) []string {
+	start := -1
+	depth := 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++ // Skip escaped character
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+
+			alternatives := splitAlternatives(pattern[start+1 : i])
+			if len(alternatives) < 2 {
+				continue // Literal braces
+			}
+
+			var result []string
+			seen := make(map[string]bool)
+			for _, alternative := range alternatives {
+				for _, expanded := range expandBraces(pattern[:start] + alternative + pattern[i+1:]) {
+					if !seen[expanded] {
+						seen[expanded] = true
+						result = append(result, expanded)
+					}
+				}
+			}
+			return result
+		}
+	}
+	return []string{pattern}
+}
+
+// splitAlternatives splits the inside of a brace group on top-level commas
+func splitAlternatives(s string) []string {
+	var parts []string
+	depth := 0
+	last := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(parts, s[last:])
+}
+
+// matchPathSegments matches path segments against pattern segments,
+// where a "**" segment matches zero or more path segments
+func matchPathSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				// A trailing "**" matches everything inside, but not the directory itself
+				return len(name) > 0
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchPathSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 || !matchSegment(pattern[0], name[0]) {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchDirPrefix reports whether files below the directory given by dir
+// could match the pattern, used to avoid walking unrelated subtrees
+func matchDirPrefix(pattern, dir []string) bool {
+	for len(dir) > 0 {
+		if len(pattern) == 0 {
+			return false
+		}
+		if pattern[0] == "**" {
+			return true
+		}
+		if !matchSegment(pattern[0], dir[0]) {
+			return false
+		}
+		pattern, dir = pattern[1:], dir[1:]
+	}
+	return len(pattern) > 0
+}
+
+// matchSegment matches a single path segment against a pattern segment
+func matchSegment(pattern, name string) bool {
+	px, nx := 0, 0
+	star, starNx := -1, 0
+
+	for px < len(pattern) || nx < len(name) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				star, starNx = px, nx
+				px++
+				continue
+			case '?':
+				if nx < len(name) {
+					_, width := utf8.DecodeRuneInString(name[nx:])
+					px++
+					nx += width
+					continue
+				}
+			case '[':
+				if nx < len(name) {
+					r, width := utf8.DecodeRuneInString(name[nx:])
+					if matched, classWidth := matchClass(pattern[px:], r); matched {
+						px += classWidth
+						nx += width
+						continue
+					}
+				}
+			case '\\':
+				if px+1 < len(pattern) && nx < len(name) {
+					// The escaped character may be a multi-byte rune
+					r, width := utf8.DecodeRuneInString(pattern[px+1:])
+					if strings.HasPrefix(name[nx:], string(r)) {
+						px += 1 + width
+						nx += width
+						continue
+					}
+				}
+			default:
+				if nx < len(name) && name[nx] == c {
+					px++
+					nx++
+					continue
+				}
+			}
+		}
+
+		// Backtrack: let the last * consume one more character
+		if star >= 0 && starNx < len(name) {
+			_, width := utf8.DecodeRuneInString(name[starNx:])
+			starNx += width
+			px, nx = star+1, starNx
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// matchClass matches r against the character class at the start of pattern.
+// It returns whether r matched and the width of the class in the pattern.
+// An unterminated class is treated as a literal '['.
+func matchClass(pattern string, r rune) (bool, int) {
+	i := 1
+	negate := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+
+	matched := false
+	first := true
+	for i < len(pattern) && (pattern[i] != ']' || first) {
+		first = false
+
+		lo, width := classChar(pattern[i:])
+		i += width
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, width = classChar(pattern[i+1:])
+			i += 1 + width
+		}
+
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+
+	if i >= len(pattern) {
+		return r == '[', 1
+	}
+	return matched != negate, i + 1
+}
+
+// classChar decodes one possibly escaped character inside a character class
+func classChar(s string) (rune, int) {
+	if s[0] == '\\' && len(s) > 1 {
+		r, width := utf8.DecodeRuneInString(s[1:])
+		return r, width + 1
+	}
+	return utf8.DecodeRuneInString(s)
+}
+
+// hasMeta reports whether s contains any glob metacharacters
+func hasMeta(s string) bool {
+	return strings.ContainsAny(s, `*?[{\`)
+}
+
+// splitGlobBase splits a slash-separated pattern into its leading literal
+// directory and the remaining pattern segments
+func splitGlobBase(pattern string) (string, []string) {
+	segments := strings.Split(pattern, "/")
+	i := 0
+	for i < len(segments) && !hasMeta(segments[i]) {
+		i++
+	}
+	return strings.Join(segments[:i], "/"), segments[i:]
+}
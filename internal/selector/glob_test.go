@@ -0,0 +1,118 @@
+package selector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "main.js", false},
+		{"*.go", "cmd/main.go", false},
+		{"?.go", "a.go", true},
+		{"?.go", "ab.go", false},
+		{"?.go", "é.go", true},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/c/main.go", true},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/x/y/c", false},
+		{"a/**", "a/x", true},
+		{"a/**", "a", false},
+		{"**/**/x", "x", true},
+		{"*.{go,js}", "main.js", true},
+		{"*.{go,js}", "main.py", false},
+		{"{cmd,internal/{config,git}}/*.go", "internal/git/git.go", true},
+		{"{cmd,internal/{config,git}}/*.go", "internal/tokenizer/bpe.go", false},
+		{"{go}", "{go}", true},
+		{"[a-c].txt", "b.txt", true},
+		{"[a-c].txt", "d.txt", false},
+		{"[!a-c].txt", "d.txt", true},
+		{"[^a-c].txt", "a.txt", false},
+		{"[]].txt", "].txt", true},
+		{"[α-ω].txt", "λ.txt", true},
+		{"[abc", "[abc", true},
+		{`\*.go`, "*.go", true},
+		{`\*.go`, "x.go", false},
+		{`\{a,b\}`, "{a,b}", true},
+		{`\é.txt`, "é.txt", true},
+		{`\é.txt`, "e.txt", false},
+		{`*\日本.md`, "readme日本.md", true},
+		{"*", "", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"*日本*", "x日本y", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.go", []string{"*.go"}},
+		{"*.{go,js}", []string{"*.go", "*.js"}},
+		{"{a,b}/{c,d}", []string{"a/c", "a/d", "b/c", "b/d"}},
+		{"x{a,{b,c}}", []string{"xa", "xb", "xc"}},
+		{"{a,a}", []string{"a"}},
+		{"{a}", []string{"{a}"}},
+		{`\{a,b}`, []string{`\{a,b}`}},
+	}
+
+	for _, tt := range tests {
+		if got := expandBraces(tt.pattern); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandBraces(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestMatchDirPrefix(t *testing.T) {
+	tests := []struct {
+		pattern []string
+		dir     []string
+		want    bool
+	}{
+		{[]string{"internal", "*.go"}, []string{"internal"}, true},
+		{[]string{"internal", "*.go"}, []string{"cmd"}, false},
+		{[]string{"internal", "*.go"}, []string{"internal", "x"}, false},
+		{[]string{"**", "*.go"}, []string{"a", "b"}, true},
+		{[]string{"a*", "**"}, []string{"abc", "d"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := matchDirPrefix(tt.pattern, tt.dir); got != tt.want {
+			t.Errorf("matchDirPrefix(%q, %q) = %v, want %v", tt.pattern, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestSplitGlobBase(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		base     string
+		segments []string
+	}{
+		{"internal/**/*.go", "internal", []string{"**", "*.go"}},
+		{"*.go", "", []string{"*.go"}},
+		{"a/b/c.go", "a/b/c.go", []string{}},
+		{"a/{b,c}/d", "a", []string{"{b,c}", "d"}},
+	}
+
+	for _, tt := range tests {
+		base, segments := splitGlobBase(tt.pattern)
+		if base != tt.base || !reflect.DeepEqual(segments, tt.segments) {
+			t.Errorf("splitGlobBase(%q) = %q, %q, want %q, %q", tt.pattern, base, segments, tt.base, tt.segments)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package selector
+
+import (
+	"bytes"
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// goImports returns the files of the packages of the module that a Go file
+// imports, leaving out tests
+func (r *importResolver) goImports(path string) []string {
+	data, err := r.src.readFile(path)
+	if err != nil {
+		return nil
+	}
+	syntax, err := parser.ParseFile(token.NewFileSet(), path, data, parser.ImportsOnly)
+	if err != nil {
+		if r.fs.verbose {
+			fmt.Fprintf(os.Stderr, r.fs.messages.FailedToParse+"\n", path, err)
+		}
+		return nil
+	}
+
+	module := r.modules.Find(filepath.Dir(path))
+	var files []string
+	for _, spec := range syntax.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if dir, ok := module.Dir(importPath); ok {
+			files = append(files, r.goPackageFiles(dir, importPath)...)
+		}
+	}
+	return files
+}
+
+// goPackageFiles returns the files of the package in dir that a build for
+// the current platform compiles. Tests, files excluded by build constraints
+// such as //go:build ignore, and files of another package, such as a stray
+// package main, are left out.
+func (r *importResolver) goPackageFiles(dir, importPath string) []string {
+	ctxt := build.Default
+	byName := make(map[string][]string)
+	var names []string
+	for _, file := range r.src.listDir(dir) {
+		if filepath.Ext(file) != ".go" || strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		data, err := r.src.readFile(file)
+		if err != nil {
+			continue
+		}
+		ctxt.OpenFile = func(string) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+		if ok, err := ctxt.MatchFile(dir, filepath.Base(file)); err != nil || !ok {
+			continue
+		}
+		syntax, err := parser.ParseFile(token.NewFileSet(), file, data, parser.PackageClauseOnly)
+		if err != nil {
+			continue
+		}
+
+		name := syntax.Name.Name
+		if byName[name] == nil {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], file)
+	}
+
+	// A directory holds one package, by convention named like its last
+	// element; otherwise take the one most files belong to. Package main
+	// can't be imported.
+	best := ""
+	for _, name := range names {
+		if name == path.Base(importPath) {
+			return byName[name]
+		}
+		if name != "main" && (best == "" || len(byName[name]) > len(byName[best])) {
+			best = name
+		}
+	}
+	return byName[best]
+}
@@ -0,0 +1,21 @@
+package selector
+
+import "testing"
+
+func TestGoImportsSkipsFilesOutsideThePackage(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/m\n")
+	writeFile(t, root, "main.go", "package main\n\nimport \"example.com/m/p\"\n\nfunc main() { p.Run() }\n")
+	writeFile(t, root, "p/p.go", "package p\n\nfunc Run() {}\n")
+	writeFile(t, root, "p/p_test.go", "package p\n")
+	writeFile(t, root, "p/gen.go", "//go:build ignore\n\npackage main\n")
+	writeFile(t, root, "p/script.go", "package main\n\nfunc main() {}\n")
+
+	fs := newTestSelector(t, root, "main.go")
+	fs.SetImportDepth(1)
+
+	_, got := selectFiles(t, fs)
+	if len(got) != 2 || got[0] != "main.go" || got[1] != "p/p.go" {
+		t.Errorf("selected %v, want [main.go p/p.go]", got)
+	}
+}
@@ -0,0 +1,180 @@
+package selector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"aicodeprep-go/internal/git"
+	"aicodeprep-go/internal/gomod"
+)
+
+// source reads files and lists directories, either in the working tree or
+// at a revision
+type source struct {
+	readFile func(path string) ([]byte, error)
+	isFile   func(path string) bool
+	listDir  func(dir string) []string                 // Absolute paths of the files in dir
+	check    func(path string) (FileInfo, bool, error) // Applies the selection rules to a file
+}
+
+// importResolver finds the local files imported by a file, caching the
+// project configuration it reads along the way
+type importResolver struct {
+	fs        *FileSelector
+	src       *source
+	modules   *gomod.Finder        // Module of go.mod files by directory
+	tsconfigs map[string]*tsconfig // Closest tsconfig.json by directory
+}
+
+// newImportResolver creates an importResolver reading from src
+func newImportResolver(fs *FileSelector, src *source) *importResolver {
+	return &importResolver{
+		fs:        fs,
+		src:       src,
+		modules:   gomod.NewFinder(src.readFile),
+		tsconfigs: make(map[string]*tsconfig),
+	}
+}
+
+// SetImportDepth adds the local files imported by the selected files, and
+// the files those import, up to depth levels; 0 disables it. Go imports are
+// resolved to the packages of the module in go.mod, JavaScript and
+// TypeScript imports to relative paths and tsconfig.json paths, and Python
+// imports to modules of the project.
+func (fs *FileSelector) SetImportDepth(depth int) {
+	fs.importDepth = depth
+}
+
+// expandImports appends the files imported by files. Added files are checked
+// like any other candidate and get a pattern index after all include
+// patterns, so they're cut first.
+func (fs *FileSelector) expandImports(files []FileInfo) ([]FileInfo, error) {
+	src, err := fs.source()
+	if err != nil {
+		return nil, err
+	}
+	resolver := newImportResolver(fs, src)
+
+	seen := make(map[string]bool) // Selected or rejected candidates
+	for _, file := range files {
+		seen[file.Path] = true
+	}
+
+	level := files
+	for depth := 1; depth <= fs.importDepth && len(level) > 0; depth++ {
+		var next []FileInfo
+		for _, file := range level {
+			if file.Binary {
+				continue
+			}
+			for _, path := range resolver.imports(file.Path) {
+				if seen[path] {
+					continue
+				}
+				seen[path] = true
+
+				imported, ok, err := src.check(path)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					imported.Pattern = len(fs.patterns) + depth - 1
+					next = append(next, imported)
+				}
+			}
+		}
+
+		if fs.verbose {
+			fmt.Fprintf(os.Stderr, fs.messages.ImportedFiles+"\n", len(next), depth)
+		}
+		files = append(files, next...)
+		level = next
+	}
+
+	return files, nil
+}
+
+// imports returns the local files imported by the file at path
+func (r *importResolver) imports(path string) []string {
+	switch filepath.Ext(path) {
+	case ".go":
+		return r.goImports(path)
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts":
+		return r.jsImports(path)
+	case ".py":
+		return r.pyImports(path)
+	default:
+		return nil
+	}
+}
+
+// source returns the source the selection is read from
+func (fs *FileSelector) source() (*source, error) {
+	if fs.rev == "" {
+		return &source{
+			readFile: os.ReadFile,
+			isFile: func(path string) bool {
+				info, err := os.Stat(path)
+				return err == nil && info.Mode().IsRegular()
+			},
+			listDir: func(dir string) []string {
+				entries, err := os.ReadDir(dir)
+				if err != nil {
+					return nil
+				}
+				var paths []string
+				for _, entry := range entries {
+					if !entry.IsDir() {
+						paths = append(paths, filepath.Join(dir, entry.Name()))
+					}
+				}
+				return paths
+			},
+			check: func(path string) (FileInfo, bool, error) {
+				file, ok := fs.checkFile(path, fs.gitignore != nil)
+				return file, ok, nil
+			},
+		}, nil
+	}
+
+	repo, err := git.Open(fs.root)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := repo.TreeFiles(fs.rev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files at %s: %w", fs.rev, err)
+	}
+
+	byDir := make(map[string][]string)
+	byPath := make(map[string]git.TreeEntry)
+	for _, entry := range entries {
+		absPath := filepath.Join(repo.Root, filepath.FromSlash(entry.Path))
+		byDir[filepath.Dir(absPath)] = append(byDir[filepath.Dir(absPath)], absPath)
+		byPath[absPath] = entry
+	}
+
+	return &source{
+		readFile: func(path string) ([]byte, error) {
+			entry, ok := byPath[path]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return repo.ReadFile(fs.rev, entry.Path)
+		},
+		isFile: func(path string) bool {
+			_, ok := byPath[path]
+			return ok
+		},
+		listDir: func(dir string) []string {
+			paths := append([]string(nil), byDir[dir]...)
+			sort.Strings(paths)
+			return paths
+		},
+		check: func(path string) (FileInfo, bool, error) {
+			return fs.checkRevEntry(repo, byPath[path])
+		},
+	}, nil
+}
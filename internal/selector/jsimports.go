@@ -0,0 +1,232 @@
+package selector
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// jsImportPattern matches the module specifiers of ES module imports and
+// exports, dynamic imports and CommonJS requires
+var jsImportPattern = regexp.MustCompile(`(?:\bfrom|\bimport|\bimport\s*\(|\brequire\s*\()\s*['"]([^'"\n]+)['"]`)
+
+// jsExtensions are tried in order on specifiers without an extension
+var jsExtensions = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".mts", ".cts"}
+
+// tsSources maps the extension of an emitted file to the TypeScript sources
+// it's compiled from, for ES module imports written as "./file.js"
+var tsSources = map[string][]string{
+	".js":  {".ts", ".tsx"},
+	".jsx": {".tsx"},
+	".mjs": {".mts"},
+	".cjs": {".cts"},
+}
+
+// tsconfig holds the module resolution options of a tsconfig.json or
+// jsconfig.json
+type tsconfig struct {
+	baseURL   string              // Absolute, empty when not set
+	paths     map[string][]string // Aliases like "@/*": ["src/*"]
+	pathsBase string              // Directory the paths are relative to
+}
+
+// jsImports returns the local files imported by a JavaScript or TypeScript
+// file. Relative specifiers are resolved from the file, others through the
+// paths and baseUrl of the closest tsconfig.json; packages are left out.
+func (r *importResolver) jsImports(path string) []string {
+	data, err := r.src.readFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, match := range jsImportPattern.FindAllSubmatch(data, -1) {
+		if file := r.resolveJS(filepath.Dir(path), string(match[1])); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// resolveJS resolves a module specifier imported from dir to a file
+func (r *importResolver) resolveJS(dir, spec string) string {
+	spec, _, _ = strings.Cut(spec, "?")
+	if spec == "." || spec == ".." || strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") {
+		return r.resolveJSPath(filepath.Join(dir, filepath.FromSlash(spec)))
+	}
+
+	config := r.tsconfig(dir)
+	if config == nil {
+		return ""
+	}
+
+	// The longest matching alias prefix wins, as in the TypeScript compiler
+	patterns := make([]string, 0, len(config.paths))
+	for pattern := range config.paths {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		return len(patterns[i]) > len(patterns[j])
+	})
+	for _, pattern := range patterns {
+		prefix, suffix, wildcard := strings.Cut(pattern, "*")
+		var capture string
+		if wildcard {
+			if !strings.HasPrefix(spec, prefix) || !strings.HasSuffix(spec, suffix) || len(spec) < len(prefix)+len(suffix) {
+				continue
+			}
+			capture = spec[len(prefix) : len(spec)-len(suffix)]
+		} else if spec != pattern {
+			continue
+		}
+
+		for _, target := range config.paths[pattern] {
+			target = strings.Replace(target, "*", capture, 1)
+			if file := r.resolveJSPath(filepath.Join(config.pathsBase, filepath.FromSlash(target))); file != "" {
+				return file
+			}
+		}
+	}
+
+	if config.baseURL != "" {
+		return r.resolveJSPath(filepath.Join(config.baseURL, filepath.FromSlash(spec)))
+	}
+	return ""
+}
+
+// resolveJSPath resolves an import path to a file, trying extensions and
+// index files like Node.js and TypeScript bundler resolution
+func (r *importResolver) resolveJSPath(path string) string {
+	if r.src.isFile(path) {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	for _, source := range tsSources[ext] {
+		if candidate := strings.TrimSuffix(path, ext) + source; r.src.isFile(candidate) {
+			return candidate
+		}
+	}
+	for _, ext := range jsExtensions {
+		if r.src.isFile(path + ext) {
+			return path + ext
+		}
+	}
+	for _, ext := range jsExtensions {
+		if candidate := filepath.Join(path, "index"+ext); r.src.isFile(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// tsconfig returns the configuration of the closest tsconfig.json or
+// jsconfig.json above dir, or nil if there is none
+func (r *importResolver) tsconfig(dir string) *tsconfig {
+	if config, ok := r.tsconfigs[dir]; ok {
+		return config
+	}
+
+	var config *tsconfig
+	for _, name := range []string{"tsconfig.json", "jsconfig.json"} {
+		if config = r.loadTSConfig(filepath.Join(dir, name), 0); config != nil {
+			break
+		}
+	}
+	if config == nil {
+		if parent := filepath.Dir(dir); parent != dir {
+			config = r.tsconfig(parent)
+		}
+	}
+
+	r.tsconfigs[dir] = config
+	return config
+}
+
+// loadTSConfig reads the configuration at path, following relative
+// "extends" up to a few levels
+func (r *importResolver) loadTSConfig(path string, depth int) *tsconfig {
+	data, err := r.src.readFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var raw struct {
+		Extends         string `json:"extends"`
+		CompilerOptions struct {
+			BaseURL *string             `json:"baseUrl"`
+			Paths   map[string][]string `json:"paths"`
+		} `json:"compilerOptions"`
+	}
+	if err := json.Unmarshal(stripJSONC(data), &raw); err != nil {
+		return nil
+	}
+
+	dir := filepath.Dir(path)
+	config := &tsconfig{pathsBase: dir}
+	if strings.HasPrefix(raw.Extends, ".") && depth < 4 {
+		base := filepath.Join(dir, filepath.FromSlash(raw.Extends))
+		if filepath.Ext(base) != ".json" {
+			base += ".json"
+		}
+		if parent := r.loadTSConfig(base, depth+1); parent != nil {
+			*config = *parent
+		}
+	}
+
+	if raw.CompilerOptions.BaseURL != nil {
+		config.baseURL = filepath.Join(dir, filepath.FromSlash(*raw.CompilerOptions.BaseURL))
+		config.pathsBase = config.baseURL
+	}
+	if raw.CompilerOptions.Paths != nil {
+		config.paths = raw.CompilerOptions.Paths
+		if raw.CompilerOptions.BaseURL == nil && config.baseURL == "" {
+			// Without baseUrl, paths are relative to the file declaring them
+			config.pathsBase = dir
+		}
+	}
+	return config
+}
+
+// stripJSONC removes the comments and trailing commas tsconfig.json allows
+// from data, leaving strings untouched
+func stripJSONC(data []byte) []byte {
+	result := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		switch {
+		case data[i] == '"':
+			start := i
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+			end := min(i+1, len(data))
+			result = append(result, data[start:end]...)
+		case data[i] == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case data[i] == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case data[i] == '}' || data[i] == ']':
+			// Drop a comma before the closing bracket
+			trimmed := bytes.TrimRight(result, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				result = append(result[:len(trimmed)-1], result[len(trimmed):]...)
+			}
+			result = append(result, data[i])
+		default:
+			result = append(result, data[i])
+		}
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package selector
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		name  string
+		jsonc string
+		want  string
+	}{
+		{"line comment", "{\n  // comment\n  \"a\": 1\n}", `{"a": 1}`},
+		{"block comment", `{/* a: 2, */ "a": /* inline */ 1}`, `{"a": 1}`},
+		{"trailing comma in object", "{\"a\": 1,\n}", `{"a": 1}`},
+		{"trailing comma in array", `{"a": [1, 2, ]}`, `{"a": [1, 2]}`},
+		{"comment after trailing comma", "{\"a\": [1,\n// last\n],}", `{"a": [1]}`},
+		{"comment markers in strings", `{"a": "//x", "b": "/*y*/"}`, `{"a": "//x", "b": "/*y*/"}`},
+		{"escaped quote", `{"a": "say \"// hi\"", }`, `{"a": "say \"// hi\""}`},
+		{"comma in string", `{"a": ",}"}`, `{"a": ",}"}`},
+	}
+
+	for _, tt := range tests {
+		var got, want any
+		if err := json.Unmarshal(stripJSONC([]byte(tt.jsonc)), &got); err != nil {
+			t.Errorf("%s: %v in %q", tt.name, err, stripJSONC([]byte(tt.jsonc)))
+			continue
+		}
+		if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestResolveJS(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "tsconfig.base.json", `{
+  "compilerOptions": {
+    "baseUrl": ".", // Relative to this file
+    "paths": {
+      "@/*": ["src/*"],
+      "@/components/*": ["src/ui/*"],
+      "@lib": ["lib/index.ts"],
+    },
+  },
+}`)
+	writeFile(t, root, "tsconfig.json", "/* Project */\n{\"extends\": \"./tsconfig.base\", \"include\": [\"src\"],}\n")
+	writeFile(t, root, "other/tsconfig.json", `{"compilerOptions": {"paths": {"#/*": ["./lib/*"]}}}`)
+	for _, file := range []string{
+		"src/app.ts", "src/util.ts", "src/esm.ts", "src/legacy.js", "src/ui/button.tsx",
+		"src/helpers/index.js", "src/view.jsx", "lib/index.ts", "shared/config.ts",
+		"other/main.ts", "other/lib/x.ts",
+	} {
+		writeFile(t, root, file, "export {}\n")
+	}
+	r := newTestResolver(t, root)
+
+	tests := []struct {
+		from string
+		spec string
+		want string
+	}{
+		{"src", "./util", "src/util.ts"},
+		{"src", "./util?raw", "src/util.ts"},
+		{"src", "./legacy.js", "src/legacy.js"},
+		{"src", "./esm.js", "src/esm.ts"}, // Emitted name of a TypeScript source
+		{"src", "./view", "src/view.jsx"}, // Extension tried
+		{"src", "./helpers", "src/helpers/index.js"},
+		{"src/ui", "..", ""}, // No src/index.*
+		{"src/ui", "../util", "src/util.ts"},
+		{"src", "@/util", "src/util.ts"},                    // paths wildcard from the extended config
+		{"src", "@/components/button", "src/ui/button.tsx"}, // Longest alias prefix wins
+		{"src", "@lib", "lib/index.ts"},                     // Exact alias
+		{"src", "shared/config", "shared/config.ts"},        // baseUrl
+		{"other", "#/x", "other/lib/x.ts"},                  // paths without baseUrl are relative to their file
+		{"other", "shared/config", ""},                      // Closest config has no baseUrl
+		{"src", "react", ""},
+		{"src", "./missing", ""},
+	}
+
+	for _, tt := range tests {
+		got := r.resolveJS(filepath.Join(root, filepath.FromSlash(tt.from)), tt.spec)
+		if got != "" {
+			got = relPaths(t, root, []string{got})[0]
+		}
+		if got != tt.want {
+			t.Errorf("resolveJS(%s, %q) = %q, want %q", tt.from, tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestJSImports(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "app.ts", `import { a } from './a';
+import './b.js';
+export * from "./c";
+const d = await import('./d');
+const e = require("./e");
+import React from 'react';
+`)
+	for _, file := range []string{"a.ts", "b.ts", "c.tsx", "d.js", "e.cjs"} {
+		writeFile(t, root, file, "")
+	}
+	r := newTestResolver(t, root)
+
+	got := relPaths(t, root, r.jsImports(filepath.Join(root, "app.ts")))
+	want := []string{"a.ts", "b.ts", "c.tsx", "d.js", "e.cjs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("jsImports = %v, want %v", got, want)
+	}
+}
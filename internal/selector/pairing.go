@@ -0,0 +1,160 @@
+package selector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TestPairing adds the test counterparts of selected source files, or the
+// reverse
+type TestPairing int
+
+const (
+	TestsNone    TestPairing = iota // Don't pair files
+	TestsOnly                       // Add the tests of selected sources
+	TestsSources                    // Add the sources of selected tests
+	TestsBoth                       // Add counterparts in both directions
+)
+
+// SetTestPairing adds the counterparts of the selected files according to
+// conventions, which map a source file template to its test file templates,
+// e.g. "*.go": ["*_test.go"] or "*.ts": ["*.test.ts", "__tests__/*.ts"].
+// Templates are relative to the source file's directory and contain one
+// "*" standing for the shared part of the file names.
+func (fs *FileSelector) SetTestPairing(mode TestPairing, conventions map[string][]string) error {
+	for source, tests := range conventions {
+		for _, template := range append([]string{source}, tests...) {
+			if err := checkTemplate(template); err != nil {
+				return fmt.Errorf("invalid test convention '%s': %w", template, err)
+			}
+		}
+	}
+
+	fs.testPairing = mode
+	fs.testConventions = conventions
+	return nil
+}
+
+// checkTemplate validates a file name template of a test convention
+func checkTemplate(template string) error {
+	if strings.Count(template, "*") != 1 {
+		return fmt.Errorf("expected exactly one '*'")
+	}
+	if strings.HasPrefix(template, "/") {
+		return fmt.Errorf("must be relative")
+	}
+	parts := strings.Split(template, "/")
+	for _, part := range parts[:len(parts)-1] {
+		if strings.Contains(part, "*") {
+			return fmt.Errorf("'*' is only allowed in the file name")
+		}
+		if part == ".." || part == "." || part == "" {
+			return fmt.Errorf("directories must be plain names")
+		}
+	}
+	return nil
+}
+
+// pairTests appends the existing counterparts of files. Added files are
+// checked like any other candidate and share the pattern index of the file
+// they were paired with.
+func (fs *FileSelector) pairTests(files []FileInfo) ([]FileInfo, error) {
+	src, err := fs.source()
+	if err != nil {
+		return nil, err
+	}
+
+	// Sorted for a stable order of the added files
+	sources := make([]string, 0, len(fs.testConventions))
+	for source := range fs.testConventions {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	seen := make(map[string]bool)
+	for _, file := range files {
+		seen[file.Path] = true
+	}
+
+	var added []FileInfo
+	for _, file := range files {
+		if file.Binary {
+			continue
+		}
+
+		var candidates []string
+		for _, source := range sources {
+			tests := fs.testConventions[source]
+			if fs.testPairing == TestsOnly || fs.testPairing == TestsBoth {
+				if dir, stem, ok := matchTemplate(source, file.Path); ok {
+					for _, test := range tests {
+						candidates = append(candidates, expandTemplate(dir, test, stem))
+					}
+				}
+			}
+			if fs.testPairing == TestsSources || fs.testPairing == TestsBoth {
+				for _, test := range tests {
+					if dir, stem, ok := matchTemplate(test, file.Path); ok {
+						candidates = append(candidates, expandTemplate(dir, source, stem))
+					}
+				}
+			}
+		}
+
+		for _, path := range candidates {
+			if seen[path] || !src.isFile(path) {
+				continue
+			}
+			seen[path] = true
+
+			paired, ok, err := src.check(path)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				paired.Pattern = file.Pattern
+				added = append(added, paired)
+			}
+		}
+	}
+
+	if fs.verbose {
+		fmt.Fprintf(os.Stderr, fs.messages.PairedFiles+"\n", len(added))
+	}
+	return append(files, added...), nil
+}
+
+// matchTemplate matches the end of path against a template, returning the
+// directory the template is relative to and the part of the file name the
+// "*" stands for
+func matchTemplate(template, path string) (string, string, bool) {
+	parts := strings.Split(template, "/")
+	pathParts := strings.Split(filepath.ToSlash(path), "/")
+	if len(pathParts) <= len(parts) {
+		return "", "", false
+	}
+	tail := pathParts[len(pathParts)-len(parts):]
+
+	for i, part := range parts[:len(parts)-1] {
+		if tail[i] != part {
+			return "", "", false
+		}
+	}
+
+	name := tail[len(tail)-1]
+	prefix, suffix, _ := strings.Cut(parts[len(parts)-1], "*")
+	if len(name) <= len(prefix)+len(suffix) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return "", "", false
+	}
+
+	dir := filepath.FromSlash(strings.Join(pathParts[:len(pathParts)-len(parts)], "/"))
+	return dir, name[len(prefix) : len(name)-len(suffix)], true
+}
+
+// expandTemplate returns the path a template names in dir for stem
+func expandTemplate(dir, template, stem string) string {
+	return filepath.Join(dir, filepath.FromSlash(strings.Replace(template, "*", stem, 1)))
+}
@@ -0,0 +1,22 @@
+package selector
+
+import "testing"
+
+func TestPairTestsOfImportedFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/m\n")
+	writeFile(t, root, "main.go", "package main\n\nimport \"example.com/m/p\"\n\nfunc main() { p.Run() }\n")
+	writeFile(t, root, "p/p.go", "package p\n\nfunc Run() {}\n")
+	writeFile(t, root, "p/p_test.go", "package p\n")
+
+	fs := newTestSelector(t, root, "main.go")
+	fs.SetImportDepth(1)
+	if err := fs.SetTestPairing(TestsOnly, map[string][]string{"*.go": {"*_test.go"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, got := selectFiles(t, fs)
+	if len(got) != 3 || got[0] != "main.go" || got[1] != "p/p.go" || got[2] != "p/p_test.go" {
+		t.Errorf("selected %v, want [main.go p/p.go p/p_test.go]", got)
+	}
+}
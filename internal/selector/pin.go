@@ -0,0 +1,25 @@
+package selector
+
+import (
+	"fmt"
+	"os"
+)
+
+// SetPins sets patterns of files that are kept first when the output is cut
+// to a token budget. Patterns are relative to the working directory.
+func (fs *FileSelector) SetPins(patterns []string) {
+	fs.pins = patterns
+}
+
+// markPinned flags the files matching a pin pattern
+func (fs *FileSelector) markPinned(files []FileInfo) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	for i := range files {
+		files[i].Pinned = matchAny(fs.pins, wd, files[i].Path) >= 0
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package selector
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pyImportPattern matches "import a.b, c as d" statements
+var pyImportPattern = regexp.MustCompile(`(?m)^[ \t]*import[ \t]+([\w.]+(?:[ \t]+as[ \t]+\w+)?(?:[ \t]*,[ \t]*[\w.]+(?:[ \t]+as[ \t]+\w+)?)*)`)
+
+// pyFromPattern matches "from .a import b, c" statements, with the names
+// possibly in parentheses over several lines
+var pyFromPattern = regexp.MustCompile(`(?m)^[ \t]*from[ \t]+(\.*[\w.]*)[ \t]+import[ \t]+(\([^)]*\)|[^\n#;]*)`)
+
+// pyImports returns the local modules imported by a Python file. Relative
+// imports are resolved from the file's package; absolute ones from the
+// file's directory, the directory above its top-level package, the project
+// root and a src directory in it. Modules found in none of them, like the
+// standard library and installed packages, are left out.
+func (r *importResolver) pyImports(path string) []string {
+	data, err := r.src.readFile(path)
+	if err != nil {
+		return nil
+	}
+	dir := filepath.Dir(path)
+	roots := r.pyRoots(dir)
+
+	var files []string
+	for _, match := range pyImportPattern.FindAllSubmatch(data, -1) {
+		for _, item := range strings.Split(string(match[1]), ",") {
+			module := strings.Fields(item)[0]
+			for _, root := range roots {
+				if file := r.resolvePyModule(root, module); file != "" {
+					files = append(files, file)
+					break
+				}
+			}
+		}
+	}
+
+	for _, match := range pyFromPattern.FindAllSubmatch(data, -1) {
+		module := string(match[1])
+		names := pyImportedNames(string(match[2]))
+
+		bases := roots
+		if dots := len(module) - len(strings.TrimLeft(module, ".")); dots > 0 {
+			base := dir
+			for i := 1; i < dots; i++ {
+				base = filepath.Dir(base)
+			}
+			bases = []string{base}
+			module = module[dots:]
+		}
+
+		for _, base := range bases {
+			// The names may be submodules as well as attributes of the module
+			found := false
+			if file := r.resolvePyModule(base, module); file != "" {
+				files = append(files, file)
+				found = true
+			}
+			for _, name := range names {
+				submodule := name
+				if module != "" {
+					submodule = module + "." + name
+				}
+				if file := r.resolvePyModule(base, submodule); file != "" {
+					files = append(files, file)
+					found = true
+				}
+			}
+			if found {
+				break
+			}
+		}
+	}
+	return files
+}
+
+// pyImportedNames returns the names of a from-import, without aliases
+func pyImportedNames(list string) []string {
+	list = strings.Trim(strings.TrimSpace(list), "()")
+	var names []string
+	for _, item := range strings.Split(list, ",") {
+		fields := strings.Fields(item)
+		if len(fields) > 0 && fields[0] != "*" {
+			names = append(names, fields[0])
+		}
+	}
+	return names
+}
+
+// pyRoots returns the directories absolute imports from dir are looked up in
+func (r *importResolver) pyRoots(dir string) []string {
+	top := dir
+	for r.src.isFile(filepath.Join(top, "__init__.py")) {
+		parent := filepath.Dir(top)
+		if parent == top {
+			break
+		}
+		top = parent
+	}
+
+	var roots []string
+	seen := make(map[string]bool)
+	for _, root := range []string{dir, top, r.fs.root, filepath.Join(r.fs.root, "src")} {
+		if !seen[root] {
+			seen[root] = true
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// resolvePyModule resolves a dotted module name below base to its file, a
+// module or a package's __init__.py. An empty name is the package at base.
+func (r *importResolver) resolvePyModule(base, module string) string {
+	path := filepath.Join(base, filepath.FromSlash(strings.ReplaceAll(module, ".", "/")))
+	if module != "" && r.src.isFile(path+".py") {
+		return path + ".py"
+	}
+	if init := filepath.Join(path, "__init__.py"); r.src.isFile(init) {
+		return init
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package selector
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPyImportedNames(t *testing.T) {
+	tests := []struct {
+		list string
+		want []string
+	}{
+		{"a", []string{"a"}},
+		{"a, b as c", []string{"a", "b"}},
+		{"(a,\n    b as c,\n)", []string{"a", "b"}},
+		{"*", nil},
+	}
+
+	for _, tt := range tests {
+		if got := pyImportedNames(tt.list); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("pyImportedNames(%q) = %q, want %q", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestPyImports(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{
+		"app/__init__.py", "app/models.py", "app/utils/__init__.py", "app/utils/strings.py",
+		"app/sub/__init__.py", "app/sub/helpers.py", "config.py", "src/mylib.py",
+	} {
+		writeFile(t, root, file, "")
+	}
+	r := newTestResolver(t, root)
+
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{"relative package", "from . import helpers\n", []string{"app/sub/__init__.py", "app/sub/helpers.py"}},
+		{"relative parent", "from ..utils import strings\n", []string{"app/utils/__init__.py", "app/utils/strings.py"}},
+		{"relative attribute", "from ..models import User as U\n", []string{"app/models.py"}},
+		{"aliased names", "from app.utils import (\n    strings as s,\n    missing,\n)\n", []string{"app/utils/__init__.py", "app/utils/strings.py"}},
+		{"absolute from top package", "import app.models as m\n", []string{"app/models.py"}},
+		{"absolute from the root", "import os, config\n", []string{"config.py"}},
+		{"absolute from src", "import mylib\n", []string{"src/mylib.py"}},
+		{"absolute sibling", "import helpers\n", []string{"app/sub/helpers.py"}},
+		{"standard library", "import os.path\nfrom typing import List\n", nil},
+	}
+
+	path := filepath.Join(root, "app", "sub", "handler.py")
+	for _, tt := range tests {
+		writeFile(t, root, "app/sub/handler.py", tt.source)
+		got := relPaths(t, root, r.pyImports(path))
+		if len(got) == 0 {
+			got = nil
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: pyImports = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPyRoots(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "app/__init__.py", "")
+	writeFile(t, root, "app/sub/__init__.py", "")
+	r := newTestResolver(t, root)
+
+	got := relPaths(t, root, r.pyRoots(filepath.Join(root, "app", "sub")))
+	want := []string{"app/sub", ".", "src"} // The directory above the top package is the root
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pyRoots = %v, want %v", got, want)
+	}
+}
@@ -5,6 +5,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"aicodeprep-go/internal/git"
+	"aicodeprep-go/internal/i18n"
 )
 
 // FileSelector handles file selection with glob patterns and exclusions
@@ -12,25 +16,143 @@ type FileSelector struct {
 	patterns    []string
 	excludes    []string
 	maxFileSize int64
+	root        string
+	excluder    *excludeMatcher
+	gitignore   *gitignore
+	exclusions  []Exclusion
+	verbose     bool
+	messages    *i18n.Messages
+	gitMode     GitMode
+	gitRef      string
+	rev         string
+	pins        []string
+
+	binaryPlaceholders bool
+	encodings          map[string]string // Forced encodings by glob
+	encodingPatterns   []string          // Globs of encodings, longest first
+	skeleton           []string
+	importDepth        int // Levels of imported files added to the selection
+	testPairing        TestPairing
+	testConventions    map[string][]string // Test file templates by source file template
+	blobs              *git.BlobReader     // Reads the start of files of the revision, open during SelectFiles
 }
 
 // FileInfo contains information about a selected file
 type FileInfo struct {
 	Path string
 	Size int64
+	Rev  string // Git revision the file is read from, empty for the working tree
+
+	Pattern int  // Index of the first include pattern that matched, after all patterns for imported files
+	Pinned  bool // Matched a pin pattern and is kept first when cutting to a budget
+
+	Binary bool   // Listed as a placeholder instead of its content
+	MIME   string // Content type of a binary file
+
+	Encoding string // Forced encoding of the content, empty to detect it
+	Skeleton bool   // Rendered as declarations only
 }
 
 // New creates a new FileSelector
 func New(patterns []string, excludes []string, maxFileSize int64) *FileSelector {
+	root := findProjectRoot()
 	return &FileSelector{
 		patterns:    patterns,
 		excludes:    excludes,
 		maxFileSize: maxFileSize,
+		root:        root,
+		excluder:    newExcludeMatcher(root, excludes),
+		messages:    i18n.English,
+	}
+}
+
+// SetRoot sets the project root that exclude and .gitignore rules are relative to
+func (fs *FileSelector) SetRoot(root string) error {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve root '%s': %w", root, err)
+	}
+
+	fs.root = absRoot
+	fs.excluder = newExcludeMatcher(absRoot, fs.excludes)
+	if fs.gitignore != nil {
+		fs.gitignore = newGitignore(absRoot)
+	}
+	return nil
+}
+
+// SetVerbose enables progress and timing output on stderr
+func (fs *FileSelector) SetVerbose(verbose bool) {
+	fs.verbose = verbose
+}
+
+// SetMessages sets the language of the progress printed in verbose mode
+func (fs *FileSelector) SetMessages(messages *i18n.Messages) {
+	fs.messages = messages
+}
+
+// Root returns the project root
+func (fs *FileSelector) Root() string {
+	return fs.root
+}
+
+// SetRespectGitignore enables or disables filtering by .gitignore rules
+func (fs *FileSelector) SetRespectGitignore(enabled bool) {
+	if enabled {
+		fs.gitignore = newGitignore(fs.root)
+	} else {
+		fs.gitignore = nil
 	}
 }
 
 // SelectFiles selects files based on patterns and exclusions
 func (fs *FileSelector) SelectFiles() ([]FileInfo, error) {
+	fs.exclusions = nil
+	defer fs.closeBlobs()
+
+	var files []FileInfo
+	var err error
+	switch {
+	case fs.rev != "":
+		files, err = fs.selectRevFiles()
+	case fs.gitMode != GitNone:
+		files, err = fs.selectGitFiles()
+	default:
+		files, err = fs.selectPatternFiles()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Imported files are paired too
+	if fs.importDepth > 0 {
+		files, err = fs.expandImports(files)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if fs.testPairing != TestsNone {
+		files, err = fs.pairTests(files)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(fs.pins) > 0 {
+		if err := fs.markPinned(files); err != nil {
+			return nil, err
+		}
+	}
+	if len(fs.skeleton) > 0 {
+		if err := fs.markSkeleton(files); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// selectPatternFiles expands the include patterns on the file system
+func (fs *FileSelector) selectPatternFiles() ([]FileInfo, error) {
 	var files []FileInfo
 	processedFiles := make(map[string]bool) // Prevent duplicates
 
@@ -40,7 +162,7 @@ func (fs *FileSelector) SelectFiles() ([]FileInfo, error) {
 		patterns = []string{"*"}
 	}
 
-	for _, pattern := range patterns {
+	for i, pattern := range patterns {
 		matches, err := fs.expandGlob(pattern)
 		if err != nil {
 			return nil, fmt.Errorf("failed to expand pattern '%s': %w", pattern, err)
@@ -52,185 +174,175 @@ func (fs *FileSelector) SelectFiles() ([]FileInfo, error) {
 			}
 			processedFiles[match] = true
 
-			// Check if file should be excluded
-			if fs.isExcluded(match) {
-				continue
-			}
-
-			// Check if it's a regular file
-			info, err := os.Stat(match)
-			if err != nil {
-				continue // Skip files that can't be accessed
+			if file, ok := fs.checkFile(match, fs.gitignore != nil); ok {
+				file.Pattern = i
+				files = append(files, file)
 			}
+		}
+	}
 
-			if !info.Mode().IsRegular() {
-				continue // Skip directories and special files
-			}
+	return files, nil
+}
 
-			// Check file size
-			if fs.maxFileSize > 0 && info.Size() > fs.maxFileSize {
-				continue // Skip files that are too large
-			}
+// checkFile applies exclude rules, .gitignore rules and the size limit to a candidate path
+func (fs *FileSelector) checkFile(path string, useGitignore bool) (FileInfo, bool) {
+	// Check if file should be excluded
+	if rule := fs.excluder.Excluded(path, false); rule != nil {
+		fs.recordExclusion(path, false, rule.String())
+		return FileInfo{}, false
+	}
 
-			files = append(files, FileInfo{
-				Path: match,
-				Size: info.Size(),
-			})
+	// Check if file is ignored by git
+	if useGitignore {
+		if rule := fs.gitignore.Ignored(path, false); rule != nil {
+			fs.recordExclusion(path, false, rule.String())
+			return FileInfo{}, false
 		}
 	}
 
-	return files, nil
-}
+	// Check if it's a regular file
+	info, err := os.Stat(path)
+	if err != nil {
+		return FileInfo{}, false // Skip files that can't be accessed
+	}
 
-// expandGlob expands a glob pattern, handling both simple globs and recursive patterns
-func (fs *FileSelector) expandGlob(pattern string) ([]string, error) {
-	// Handle recursive patterns like "src/**/*.go"
-	if strings.Contains(pattern, "**") {
-		return fs.expandRecursiveGlob(pattern)
+	if !info.Mode().IsRegular() {
+		return FileInfo{}, false // Skip directories and special files
 	}
 
-	// Handle simple glob patterns
-	matches, err := filepath.Glob(pattern)
+	// Check file size
+	if fs.maxFileSize > 0 && info.Size() > fs.maxFileSize {
+		fs.recordExclusion(path, false, fmt.Sprintf(fs.messages.ExcludedTooLarge, fs.maxFileSize))
+		return FileInfo{}, false // Skip files that are too large
+	}
+
+	file := FileInfo{
+		Path:     path,
+		Size:     info.Size(),
+		Encoding: fs.forcedEncoding(path),
+	}
+
+	// Check the content for binary data
+	binary, mime, err := sniffFile(path, file.Encoding)
 	if err != nil {
-		return nil, err
+		fs.recordExclusion(path, false, fmt.Sprintf(fs.messages.ExcludedUnreadable, err))
+		return FileInfo{}, false
+	}
+	if !fs.checkBinary(&file, binary, mime) {
+		return FileInfo{}, false
 	}
 
-	// Convert to absolute paths
+	return file, true
+}
+
+// expandGlob expands a glob pattern, handling brace alternatives and recursive ** segments
+func (fs *FileSelector) expandGlob(pattern string) ([]string, error) {
 	var result []string
-	for _, match := range matches {
-		absPath, err := filepath.Abs(match)
+	for _, alternative := range expandBraces(filepath.ToSlash(pattern)) {
+		matches, err := fs.expandSingleGlob(alternative)
 		if err != nil {
-			continue
+			return nil, err
 		}
-		result = append(result, absPath)
+		result = append(result, matches...)
 	}
-
 	return result, nil
 }
 
-// expandRecursiveGlob handles recursive glob patterns with **
-func (fs *FileSelector) expandRecursiveGlob(pattern string) ([]string, error) {
-	var matches []string
+// expandSingleGlob walks the literal base directory of a brace-free pattern
+// and collects the files matching the remaining segments
+func (fs *FileSelector) expandSingleGlob(pattern string) ([]string, error) {
+	base, segments := splitGlobBase(pattern)
 
-	// Split pattern at first **
-	parts := strings.SplitN(pattern, "**", 2)
-	if len(parts) != 2 {
-		return filepath.Glob(pattern)
+	// A pattern without metacharacters names a single path
+	if len(segments) == 0 {
+		if _, err := os.Lstat(filepath.FromSlash(base)); err != nil {
+			return nil, nil
+		}
+		absPath, err := filepath.Abs(filepath.FromSlash(base))
+		if err != nil {
+			return nil, nil
+		}
+		return []string{absPath}, nil
 	}
 
-	prefix := parts[0]
-	suffix := parts[1]
-
-	// Remove trailing / from prefix and leading / from suffix
-	prefix = strings.TrimSuffix(prefix, "/")
-	suffix = strings.TrimPrefix(suffix, "/")
-
-	// If prefix is empty, start from current directory
-	if prefix == "" {
+	prefix := filepath.FromSlash(base)
+	if base == "" {
+		// If prefix is empty, start from current directory
 		prefix = "."
+		if strings.HasPrefix(pattern, "/") {
+			prefix = string(filepath.Separator)
+		}
+	}
+	if _, err := os.Stat(prefix); err != nil {
+		return nil, nil
 	}
 
+	var matches []string
+	var dirs, files, pruned int
+	start := time.Now()
+
 	// Walk the directory tree
 	err := filepath.WalkDir(prefix, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return nil // Continue walking even if there are errors
 		}
+		if path == prefix {
+			return nil
+		}
 
-		if d.IsDir() {
-			return nil // We only want files
+		relPath, err := filepath.Rel(prefix, path)
+		if err != nil {
+			return nil
 		}
+		relSegments := strings.Split(filepath.ToSlash(relPath), "/")
 
-		// Check if the file matches the suffix pattern
-		if suffix == "" {
-			// No suffix pattern, match everything
-			absPath, err := filepath.Abs(path)
-			if err == nil {
-				matches = append(matches, absPath)
+		if d.IsDir() {
+			// Don't descend into directories the pattern can't match below
+			if !matchDirPrefix(segments, relSegments) {
+				return filepath.SkipDir
 			}
-		} else {
-			// Extract the relative path from the prefix
-			relPath, err := filepath.Rel(prefix, path)
+
+			absPath, err := filepath.Abs(path)
 			if err != nil {
 				return nil
 			}
 
-			// Check if the relative path matches the suffix pattern
-			matched, err := filepath.Match(suffix, relPath)
-			if err != nil {
-				return nil
+			// Don't descend into excluded directories
+			if rule := fs.excluder.ExcludedDir(absPath); rule != nil {
+				fs.recordExclusion(absPath, true, rule.String())
+				pruned++
+				return filepath.SkipDir
 			}
 
-			if matched {
-				absPath, err := filepath.Abs(path)
-				if err == nil {
-					matches = append(matches, absPath)
-				}
-			} else {
-				// Try matching just the filename
-				matched, err := filepath.Match(suffix, filepath.Base(path))
-				if err == nil && matched {
-					absPath, err := filepath.Abs(path)
-					if err == nil {
-						matches = append(matches, absPath)
-					}
+			// Don't descend into directories ignored by git
+			if fs.gitignore != nil {
+				if rule := fs.gitignore.Ignored(absPath, true); rule != nil {
+					fs.recordExclusion(absPath, true, rule.String())
+					pruned++
+					return filepath.SkipDir
 				}
 			}
-		}
-
-		return nil
-	})
 
-	return matches, err
-}
+			dirs++
+			return nil // We only want files
+		}
 
-// isExcluded checks if a file path should be excluded based on exclude patterns
-func (fs *FileSelector) isExcluded(path string) bool {
-	for _, exclude := range fs.excludes {
-		// Handle recursive exclusion patterns
-		if strings.Contains(exclude, "**") {
-			if fs.matchesRecursiveExclude(path, exclude) {
-				return true
-			}
-		} else {
-			// Simple pattern matching
-			matched, err := filepath.Match(exclude, filepath.Base(path))
-			if err == nil && matched {
-				return true
-			}
+		files++
 
-			// Also try matching the full path
-			matched, err = filepath.Match(exclude, path)
-			if err == nil && matched {
-				return true
+		if matchPathSegments(segments, relSegments) {
+			absPath, err := filepath.Abs(path)
+			if err == nil {
+				matches = append(matches, absPath)
 			}
 		}
-	}
-	return false
-}
-
-// matchesRecursiveExclude checks if a path matches a recursive exclude pattern
-func (fs *FileSelector) matchesRecursiveExclude(path, pattern string) bool {
-	// Split pattern at **
-	parts := strings.SplitN(pattern, "**", 2)
-	if len(parts) != 2 {
-		matched, _ := filepath.Match(pattern, path)
-		return matched
-	}
-
-	prefix := strings.TrimSuffix(parts[0], "/")
-	suffix := strings.TrimPrefix(parts[1], "/")
 
-	// Check if path contains the prefix
-	if prefix != "" && !strings.Contains(path, prefix) {
-		return false
-	}
+		return nil
+	})
 
-	// If there's a suffix, check if it matches
-	if suffix != "" {
-		matched, _ := filepath.Match(suffix, filepath.Base(path))
-		return matched
+	if fs.verbose {
+		fmt.Fprintf(os.Stderr, fs.messages.WalkedDir+"\n",
+			prefix, pattern, dirs, files, pruned, time.Since(start).Round(time.Microsecond))
 	}
 
-	// If no suffix, any path containing prefix matches
-	return prefix == "" || strings.Contains(path, prefix)
-}
\ No newline at end of file
+	return matches, err
+}
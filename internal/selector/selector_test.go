@@ -0,0 +1,75 @@
+package selector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile creates a file below root with the given content
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestSelector creates a selector for patterns with root as the project
+// root and the working directory, as when the command runs in the project
+func newTestSelector(t *testing.T, root string, patterns ...string) *FileSelector {
+	t.Helper()
+	t.Chdir(root)
+	fs := New(patterns, nil, 0)
+	if err := fs.SetRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	return fs
+}
+
+// selectFiles runs the selection and returns the selected files along with
+// their slash-separated paths relative to the project root
+func selectFiles(t *testing.T, fs *FileSelector) ([]FileInfo, []string) {
+	t.Helper()
+	files, err := fs.SelectFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		rel, err := filepath.Rel(fs.Root(), file.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	return files, paths
+}
+
+// newTestResolver creates an import resolver for the working tree at root
+func newTestResolver(t *testing.T, root string) *importResolver {
+	t.Helper()
+	fs := newTestSelector(t, root)
+	src, err := fs.source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newImportResolver(fs, src)
+}
+
+// relPaths returns paths relative to root, slash-separated
+func relPaths(t *testing.T, root string, paths []string) []string {
+	t.Helper()
+	rels := make([]string, 0, len(paths))
+	for _, path := range paths {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	return rels
+}
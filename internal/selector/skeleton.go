@@ -0,0 +1,32 @@
+package selector
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SetSkeleton sets patterns of files rendered as declarations only. Patterns
+// are relative to the working directory; a pattern starting with "!" renders
+// matching files in full again, and the last matching pattern wins.
+func (fs *FileSelector) SetSkeleton(patterns []string) {
+	fs.skeleton = patterns
+}
+
+// markSkeleton flags the files that are rendered as declarations only
+func (fs *FileSelector) markSkeleton(files []FileInfo) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	for i := range files {
+		for _, pattern := range fs.skeleton {
+			negated := strings.HasPrefix(pattern, "!")
+			if matchAny([]string{strings.TrimPrefix(pattern, "!")}, wd, files[i].Path) >= 0 {
+				files[i].Skeleton = !negated
+			}
+		}
+	}
+	return nil
+}
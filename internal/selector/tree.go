@@ -0,0 +1,80 @@
+package selector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"aicodeprep-go/internal/git"
+)
+
+// ListAll lists every file under the project root that exclude and .gitignore
+// rules keep, regardless of the include patterns and the size limit. With a
+// revision set, the files come from the revision's tree.
+func (fs *FileSelector) ListAll() ([]FileInfo, error) {
+	if fs.rev != "" {
+		return fs.listRevision()
+	}
+
+	var files []FileInfo
+	err := filepath.WalkDir(fs.root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // Continue walking even if there are errors
+		}
+		if path == fs.root {
+			return nil
+		}
+
+		if d.IsDir() {
+			if fs.excluder.ExcludedDir(path) != nil {
+				return filepath.SkipDir
+			}
+			if fs.gitignore != nil && fs.gitignore.Ignored(path, true) != nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if fs.excluder.Excluded(path, false) != nil {
+			return nil
+		}
+		if fs.gitignore != nil && fs.gitignore.Ignored(path, false) != nil {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		files = append(files, FileInfo{Path: path, Size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project files: %w", err)
+	}
+
+	return files, nil
+}
+
+// listRevision lists the files of the revision's tree that exclude rules keep
+func (fs *FileSelector) listRevision() ([]FileInfo, error) {
+	repo, err := git.Open(fs.root)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := repo.TreeFiles(fs.rev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files at %s: %w", fs.rev, err)
+	}
+
+	var files []FileInfo
+	for _, entry := range entries {
+		absPath := filepath.Join(repo.Root, filepath.FromSlash(entry.Path))
+		if fs.excluder.Excluded(absPath, false) != nil {
+			continue
+		}
+		files = append(files, FileInfo{Path: absPath, Size: entry.Size, Rev: fs.rev})
+	}
+	return files, nil
+}
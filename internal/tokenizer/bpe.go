@@ -0,0 +1,189 @@
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Pre-tokenization patterns of the tiktoken encodings. The trailing `\s+(?!\S)`
+// alternative of the originals can't be expressed in RE2, see splitPieces.
+const (
+	cl100kPattern = `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`
+	o200kPattern  = `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+`
+)
+
+const (
+	maxCachedPieces = 1 << 16 // The cache is cleared once it holds this many pieces
+	maxPieceBytes   = 256     // Longer pieces are merged in chunks of this size
+)
+
+// bpe is a byte pair encoding tokenizer using tiktoken ranks
+type bpe struct {
+	name    string
+	ranks   map[string]int
+	pattern *regexp.Regexp
+	cache   map[string]int // Token counts of pieces seen before
+}
+
+// newBPE creates a BPE tokenizer from merge ranks and a pre-tokenization pattern
+func newBPE(name string, ranks map[string]int, pattern string) *bpe {
+	return &bpe{
+		name:    name,
+		ranks:   ranks,
+		pattern: regexp.MustCompile(pattern),
+		cache:   make(map[string]int),
+	}
+}
+
+// parseRanks parses a tiktoken rank file
+func parseRanks(data []byte) (map[string]int, error) {
+	ranks := make(map[string]int)
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		fields := bytes.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected a token and a rank", i+1)
+		}
+		token, err := base64.StdEncoding.DecodeString(string(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		rank, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		ranks[string(token)] = rank
+	}
+
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("vocabulary is empty")
+	}
+	return ranks, nil
+}
+
+// Name returns the encoding name
+func (b *bpe) Name() string {
+	return b.name
+}
+
+// Count returns the number of tokens in text
+func (b *bpe) Count(text string) int {
+	count := 0
+	for _, piece := range splitPieces(b.pattern, text) {
+		if n, ok := b.cache[piece]; ok {
+			count += n
+			continue
+		}
+		n := 0
+		for _, chunk := range chunkPiece(piece) {
+			n += b.countPiece(chunk)
+		}
+		if len(b.cache) >= maxCachedPieces {
+			clear(b.cache)
+		}
+		b.cache[piece] = n
+		count += n
+	}
+	return count
+}
+
+// chunkPiece splits piece in chunks of at most maxPieceBytes, cut at
+// character boundaries. Merging is quadratic in the length of a piece, so
+// long runs such as minified code or base64 are counted chunk by chunk, which
+// may count a few more tokens than merging them whole.
+func chunkPiece(piece string) []string {
+	var chunks []string
+	for len(piece) > maxPieceBytes {
+		cut := maxPieceBytes
+		for cut > 0 && !utf8.RuneStart(piece[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			cut = maxPieceBytes // Not UTF-8
+		}
+		chunks = append(chunks, piece[:cut])
+		piece = piece[cut:]
+	}
+	return append(chunks, piece)
+}
+
+// countPiece merges the bytes of piece, lowest rank first, until no adjacent
+// pair is in the vocabulary, and returns the number of parts left
+func (b *bpe) countPiece(piece string) int {
+	if _, ok := b.ranks[piece]; ok {
+		return 1
+	}
+
+	// bounds[i] is the start of part i; the last entry is the end of piece
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+
+	for len(bounds) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+
+	return len(bounds) - 1
+}
+
+// splitPieces splits text with the pre-tokenization pattern. A run of
+// whitespace followed by a non-space character gives up its last character
+// to the next piece, as `\s+(?!\S)` does in the original patterns.
+func splitPieces(pattern *regexp.Regexp, text string) []string {
+	var pieces []string
+	for len(text) > 0 {
+		loc := pattern.FindStringIndex(text)
+		if loc == nil || loc[0] != 0 || loc[1] == 0 {
+			// Shouldn't happen as the patterns match any character
+			_, size := utf8.DecodeRuneInString(text)
+			loc = []int{0, size}
+		}
+
+		end := loc[1]
+		piece := text[:end]
+		if end < len(text) && isSpaceRun(piece) {
+			next, _ := utf8.DecodeRuneInString(text[end:])
+			last, size := utf8.DecodeLastRuneInString(piece)
+			if !unicode.IsSpace(next) && last != '\r' && last != '\n' && size < len(piece) {
+				end -= size
+				piece = text[:end]
+			}
+		}
+
+		pieces = append(pieces, piece)
+		text = text[end:]
+	}
+	return pieces
+}
+
+// isSpaceRun reports whether s consists of whitespace only
+func isSpaceRun(s string) bool {
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package tokenizer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// heuristic estimates token counts from character classes, close to what
+// BPE encodings produce for source code and English text
+type heuristic struct{}
+
+// NewHeuristic returns a tokenizer that estimates counts without a vocabulary
+func NewHeuristic() Tokenizer {
+	return heuristic{}
+}
+
+// Name returns the encoding name
+func (heuristic) Name() string {
+	return EncodingHeuristic
+}
+
+// Count estimates the number of tokens in text
+func (heuristic) Count(text string) int {
+	count := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		j := i + size
+
+		switch {
+		case r == '\n' || r == '\r':
+			// A run of line breaks is usually one token
+			for j < len(text) && (text[j] == '\n' || text[j] == '\r') {
+				j++
+			}
+			count++
+		case r == ' ' || r == '\t':
+			// A single space joins the next word; indentation is one token
+			for j < len(text) && (text[j] == ' ' || text[j] == '\t') {
+				j++
+			}
+			if j-i > 1 || j == len(text) {
+				count++
+			}
+		case r < utf8.RuneSelf && isWordByte(byte(r)):
+			// About four characters per token for words and identifiers
+			for j < len(text) && isWordByte(text[j]) {
+				j++
+			}
+			count += (j - i + 3) / 4
+		case r >= '0' && r <= '9':
+			// Numbers are split into groups of up to three digits
+			for j < len(text) && text[j] >= '0' && text[j] <= '9' {
+				j++
+			}
+			count += (j - i + 2) / 3
+		case r < utf8.RuneSelf:
+			// Punctuation often merges in pairs such as ") {" or ":="
+			for j < len(text) && text[j] < utf8.RuneSelf && isPunct(text[j]) {
+				j++
+			}
+			count += (j - i + 1) / 2
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			// CJK characters are about one token each
+			count++
+		default:
+			// Other scripts take roughly one token per two characters
+			n := 1
+			for j < len(text) {
+				r, size := utf8.DecodeRuneInString(text[j:])
+				if r < utf8.RuneSelf || unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+					break
+				}
+				j += size
+				n++
+			}
+			count += (n + 1) / 2
+		}
+
+		i = j
+	}
+	return count
+}
+
+// isWordByte reports whether c is an ASCII letter or underscore
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+// isPunct reports whether c is ASCII punctuation
+func isPunct(c byte) bool {
+	return c > ' ' && c < utf8.RuneSelf && !isWordByte(c) && !(c >= '0' && c <= '9')
+}
@@ -0,0 +1,57 @@
+package tokenizer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Encodings supported by New
+const (
+	EncodingCL100K    = "cl100k_base" // GPT-4, GPT-3.5
+	EncodingO200K     = "o200k_base"  // GPT-4o and newer
+	EncodingHeuristic = "heuristic"   // Estimate without a vocabulary
+)
+
+// ErrNoVocabulary is returned by New when a BPE encoding is selected without
+// a vocabulary file. The rank files aren't distributed with the program.
+var ErrNoVocabulary = errors.New("no vocabulary file given")
+
+// Tokenizer counts the tokens a model sees for a text
+type Tokenizer interface {
+	// Name returns the encoding name
+	Name() string
+	// Count returns the number of tokens in text
+	Count(text string) int
+}
+
+// New returns the tokenizer for encoding, the heuristic estimate if it is
+// empty. BPE encodings read their ranks from the tiktoken file at vocabPath.
+func New(encoding, vocabPath string) (Tokenizer, error) {
+	var pattern string
+	switch encoding {
+	case EncodingHeuristic, "":
+		return NewHeuristic(), nil
+	case EncodingCL100K:
+		pattern = cl100kPattern
+	case EncodingO200K:
+		pattern = o200kPattern
+	default:
+		return nil, fmt.Errorf("unknown encoding '%s' (expected %s, %s or %s)",
+			encoding, EncodingCL100K, EncodingO200K, EncodingHeuristic)
+	}
+
+	if vocabPath == "" {
+		return nil, fmt.Errorf("%s: %w", encoding, ErrNoVocabulary)
+	}
+	data, err := os.ReadFile(vocabPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+
+	ranks, err := parseRanks(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse vocabulary: %w", err)
+	}
+	return newBPE(encoding, ranks, pattern), nil
+}
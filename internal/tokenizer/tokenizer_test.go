@@ -0,0 +1,191 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// testRanks is a small vocabulary in rank order: the single bytes used by the
+// tests followed by a few merges
+var testRanks = []string{
+	"a", "b", "c", "d", "h", "e", "l", "o", "w", "r", " ", "\n", "1", "2", "3", "4", "5",
+	"ab", "abc", "he", "ll", "hell", "hello", " w", "or", " wor", "ld", " world", "12", "123",
+}
+
+// writeVocabulary writes testRanks as a tiktoken rank file and returns its path
+func writeVocabulary(t *testing.T) string {
+	t.Helper()
+	var data strings.Builder
+	for rank, token := range testRanks {
+		fmt.Fprintf(&data, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+	path := filepath.Join(t.TempDir(), "test.tiktoken")
+	if err := os.WriteFile(path, []byte(data.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBPECount(t *testing.T) {
+	tok, err := New(EncodingCL100K, writeVocabulary(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Name() != EncodingCL100K {
+		t.Errorf("Name() = %q, want %q", tok.Name(), EncodingCL100K)
+	}
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 1},          // A whole piece in the vocabulary
+		{"hello world", 2},    // "hello", " world"
+		{"hello hello", 3},    // "hello", " " "hello"
+		{"abc abc", 3},        // "abc", " " "abc"
+		{"hello  world\n", 4}, // "hello", " ", " world", "\n"
+		{"cab", 2},            // "c" "ab"
+		{"world", 3},          // "w" "or" "ld", "or" has the lower rank
+		{"12345", 3},          // "123", "4" "5"
+		{"dd", 2},             // No merge for "dd"
+	}
+
+	for _, tt := range tests {
+		if got := tok.Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+		// A second count is served from the piece cache
+		if got := tok.Count(tt.text); got != tt.want {
+			t.Errorf("cached Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestChunkPiece(t *testing.T) {
+	tests := []struct {
+		piece string
+		want  []int // Chunk lengths
+	}{
+		{"", []int{0}},
+		{strings.Repeat("a", maxPieceBytes), []int{maxPieceBytes}},
+		{strings.Repeat("a", maxPieceBytes*2+1), []int{maxPieceBytes, maxPieceBytes, 1}},
+		{"a" + strings.Repeat("é", maxPieceBytes/2), []int{maxPieceBytes - 1, 2}}, // Not inside a character
+		{strings.Repeat("\x80", maxPieceBytes+1), []int{maxPieceBytes, 1}},
+	}
+
+	for _, tt := range tests {
+		var got []int
+		for _, chunk := range chunkPiece(tt.piece) {
+			got = append(got, len(chunk))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("chunkPiece of %d bytes = %v, want %v", len(tt.piece), got, tt.want)
+		}
+	}
+}
+
+func TestBPECacheLimit(t *testing.T) {
+	tok, err := New(EncodingCL100K, writeVocabulary(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := tok.(*bpe)
+	for i := 0; i < maxCachedPieces+10; i++ {
+		b.Count(fmt.Sprintf("%d ", i))
+	}
+	if len(b.cache) > maxCachedPieces {
+		t.Errorf("cache holds %d pieces, want at most %d", len(b.cache), maxCachedPieces)
+	}
+}
+
+func TestSplitPieces(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		want    []string
+	}{
+		{cl100kPattern, "hello  world\n", []string{"hello", " ", " world", "\n"}},
+		{cl100kPattern, "I'm here", []string{"I", "'m", " here"}},
+		{cl100kPattern, "x := 12345", []string{"x", " :=", " ", "123", "45"}},
+		{cl100kPattern, "a\n\n  b", []string{"a", "\n\n", " ", " b"}},
+		{cl100kPattern, "end  ", []string{"end", "  "}},
+		{o200kPattern, "HelloWorld", []string{"Hello", "World"}},
+		{o200kPattern, "a/b\n", []string{"a", "/b", "\n"}},
+	}
+
+	for _, tt := range tests {
+		got := splitPieces(regexp.MustCompile(tt.pattern), tt.text)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitPieces(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseRanksErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"YQ==\n",
+		"YQ== x\n",
+		"!!! 0\n",
+	}
+
+	for _, data := range tests {
+		if _, err := parseRanks([]byte(data)); err == nil {
+			t.Errorf("parseRanks(%q) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, encoding := range []string{"", EncodingHeuristic} {
+		tok, err := New(encoding, "")
+		if err != nil || tok.Name() != EncodingHeuristic {
+			t.Errorf("New(%q) = %v, %v, want the heuristic tokenizer", encoding, tok, err)
+		}
+	}
+
+	if _, err := New("p50k_base", ""); err == nil || errors.Is(err, ErrNoVocabulary) {
+		t.Errorf("New(p50k_base) error = %v, want an unknown encoding error", err)
+	}
+
+	for _, encoding := range []string{EncodingCL100K, EncodingO200K} {
+		if _, err := New(encoding, ""); !errors.Is(err, ErrNoVocabulary) {
+			t.Errorf("New(%q) error = %v, want ErrNoVocabulary", encoding, err)
+		}
+	}
+
+	if _, err := New(EncodingO200K, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("New with a missing vocabulary file succeeded")
+	}
+}
+
+func TestHeuristicCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"func main() {}", 4}, // "func", " main", "()", " {}"
+		{"identifier", 3},     // About four characters per token
+		{"1234567", 3},        // Groups of up to three digits
+		{"a\n\n\nb", 3},       // A run of line breaks is one token
+		{"\t\tx", 2},          // Indentation is one token
+		{"日本語", 3},            // One token per CJK character
+		{"привет", 3},         // Two characters per token in other scripts
+	}
+
+	tok := NewHeuristic()
+	for _, tt := range tests {
+		if got := tok.Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}